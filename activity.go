@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/lookup"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+// parses the -who and -what flags and checks them against the Salesforce rules
+// for activities. WhoId can only point at Contacts and Leads, WhatId can't.
+func parseActivityTargets(who string, what string) ([]lookup.Weighted, []lookup.Weighted, error) {
+	whoTargets, err := lookup.ParseWeighted(who)
+	if err != nil {
+		return nil, nil, fmt.Errorf("-who : %v", err)
+	}
+	for _, w := range whoTargets {
+		if !strings.EqualFold(w.Object, "contact") && !strings.EqualFold(w.Object, "lead") {
+			return nil, nil, fmt.Errorf("-who can only reference Contact or Lead, not %v", w.Object)
+		}
+	}
+	whatTargets, err := lookup.ParseWeighted(what)
+	if err != nil {
+		return nil, nil, fmt.Errorf("-what : %v", err)
+	}
+	for _, w := range whatTargets {
+		if strings.EqualFold(w.Object, "contact") || strings.EqualFold(w.Object, "lead") || strings.EqualFold(w.Object, "user") {
+			return nil, nil, fmt.Errorf("-what can't reference %v", w.Object)
+		}
+	}
+	return whoTargets, whatTargets, nil
+}
+
+// populates the WhoId and WhatId columns of an activity CSV.
+// Each row picks its target objects by weight, then an Id from that object's candidates.
+// Rows where the Who is a Lead get an empty WhatId as Salesforce doesn't allow both.
+func updateActivityTargets(cfg *config.Config, f string, sobj string, whoTargets []lookup.Weighted, whatTargets []lookup.Weighted, objIds *sync.Map, c *simpleforce.Client) error {
+	rows, err := file.CountRows(f)
+	if err != nil {
+		return err
+	}
+	var whoObjs []string
+	if len(whoTargets) > 0 {
+		whoObjs = lookup.AssignTargets(rows, whoTargets)
+		whoIds, err := assignPolymorphic(cfg, sobj, "WhoId", whoObjs, objIds, c)
+		if err != nil {
+			return err
+		}
+		if err := file.SetColumn(f, "WhoId", whoIds); err != nil {
+			return err
+		}
+	}
+	if len(whatTargets) > 0 {
+		whatObjs := lookup.AssignTargets(rows, whatTargets)
+		for i := range whoObjs {
+			if strings.EqualFold(whoObjs[i], "lead") {
+				whatObjs[i] = ""
+			}
+		}
+		whatIds, err := assignPolymorphic(cfg, sobj, "WhatId", whatObjs, objIds, c)
+		if err != nil {
+			return err
+		}
+		if err := file.SetColumn(f, "WhatId", whatIds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns an Id for each row from the candidates of the object picked for that row.
+// Candidates can be filtered per target with LOOKUP_FILTERS keys like Task.WhatId.Account,
+// rows for each target are spread using the distribution configured for sobj.col
+func assignPolymorphic(cfg *config.Config, sobj string, col string, targets []string, objIds *sync.Map, c *simpleforce.Client) ([]string, error) {
+	// the targets in the order the rows first use them, so a seeded run draws in the same order
+	rowsFor := make(map[string][]int)
+	var order []string
+	for i, t := range targets {
+		if t == "" {
+			continue
+		}
+		if _, ok := rowsFor[t]; !ok {
+			order = append(order, t)
+		}
+		rowsFor[t] = append(rowsFor[t], i)
+	}
+	var dist lookup.Distribution = lookup.Uniform{}
+	if spec := cfg.Lookups.DistributionFor(sobj, col); spec != "" {
+		var err error
+		if dist, err = lookup.ParseDistribution(spec); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]string, len(targets))
+	for _, target := range order {
+		rows := rowsFor[target]
+		candidates, err := sforce.GetCandidateIds(cfg, c, objIds, sobj, fmt.Sprintf("%v.%v", col, target), target)
+		if err != nil {
+			return nil, err
+		}
+		values := dist.Assign(len(rows), candidates)
+		if n := lookup.Unassigned(values); n > 0 {
+			log.Printf("%v.%v : %d of %d rows couldn't be given a %v and are left blank", sobj, col, n, len(rows), target)
+		}
+		for i, row := range rows {
+			ids[row] = values[i]
+		}
+		log.Printf("%v.%v assigned %d rows to %v", sobj, col, len(rows), target)
+	}
+	return ids, nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/sforce"
+	"github.com/troysellers/go-modifier/temporal"
+)
+
+// exit codes scripts can act on
+const (
+	exitOK      int = 0
+	exitFailure int = 1 // nothing was done
+	exitConfig  int = 2 // bad flags or missing settings
+	exitAuth    int = 3 // unable to log in to Salesforce
+	exitPartial int = 4 // some of the records failed
+)
+
+// a subcommand, each has its own flags and help text
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = make(map[string]command)
+
+func register(c command) {
+	commands[c.name] = c
+}
+
+// an error that decides the exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// wraps an error in flags or settings
+func configError(format string, args ...interface{}) error {
+	return &exitError{code: exitConfig, err: fmt.Errorf(format, args...)}
+}
+
+// wraps an error where some of the work was done
+func partialError(err error) error {
+	return &exitError{code: exitPartial, err: err}
+}
+
+// returns the exit code for the error
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	var fe *sforce.FailedRecordsError
+	if errors.As(err, &fe) {
+		if fe.Partial() {
+			return exitPartial
+		}
+	}
+	return exitFailure
+}
+
+// runs the subcommand named by the first argument and returns the exit code
+func run(args []string, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		if len(args) > 1 {
+			if c, ok := commands[args[1]]; ok {
+				return exitCode(c.run([]string{"-h"}))
+			}
+		}
+		usage(stderr)
+		if len(args) == 0 {
+			return exitConfig
+		}
+		return exitOK
+	}
+	c, ok := commands[args[0]]
+	if !ok {
+		if strings.HasPrefix(args[0], "-op") {
+			fmt.Fprintln(stderr, "the -op flag has been replaced by subcommands, e.g. go-modifier create -obj account -count 10")
+		} else {
+			fmt.Fprintf(stderr, "unknown command %v\n", args[0])
+		}
+		usage(stderr)
+		return exitConfig
+	}
+	err := c.run(args[1:])
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(stderr, "%v : %v\n", c.name, err)
+	}
+	return exitCode(err)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: go-modifier <command> [flags]")
+	fmt.Fprintln(w, "\ncommands:")
+	var names []string
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-12v %v\n", n, commands[n].summary)
+	}
+	fmt.Fprintln(w, "\nrun go-modifier help <command> for the flags of a command")
+	fmt.Fprintf(w, "\nexit codes: %d ok, %d failed, %d config error, %d auth error, %d partial failure\n", exitOK, exitFailure, exitConfig, exitAuth, exitPartial)
+}
+
+// returns a flag set for the command that reports errors rather than exiting
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: go-modifier %v %v\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parses the flags, anything left over is a mistake
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &exitError{code: exitConfig, err: err}
+	}
+	if fs.NArg() > 0 {
+		return configError("unexpected arguments %v", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// adds -seed and -date to a command that makes random choices
+func seedFlag(fs *flag.FlagSet) (*int64, *string) {
+	seed := fs.Int64("seed", 0, "seed the random choices so the run can be repeated, 0 picks one. Dates are made from today, so a seeded run made on another day needs -date too. The seed and the date are logged either way")
+	date := fs.String("date", "", "the day (YYYY-MM-DD) generated dates are made relative to, defaults to today")
+	return seed, date
+}
+
+// seeds the random source if a seed was given, sets the day dates are made from and logs both
+func useSeed(seed int64, date string) error {
+	if date != "" {
+		d, err := time.Parse(temporal.DateFormat, date)
+		if err != nil {
+			return configError("-date must be a day like 2024-01-31, not %v", date)
+		}
+		random.SetDate(d)
+	}
+	if seed != 0 {
+		random.SetSeed(seed)
+	}
+	log.Printf("Random seed %d, dates from %v", random.Seed(), random.Now().Format(temporal.DateFormat))
+	return nil
+}
+
+// adds -locale to a command that makes names and addresses
+func localeFlag(fs *flag.FlagSet) *string {
+	return fs.String("locale", "", "the locale of the names and addresses, or weighted locales like en_US:60,de_DE:40. Defaults to the LOCALE setting then en_US")
+}
+
+// sets the locales of the run from the flag, or the LOCALE setting when the flag isn't given, and
+// how phone numbers are written
+func useLocales(flagValue string, cfg *config.Config) error {
+	spec, name := flagValue, "-locale"
+	if spec == "" {
+		spec, name = cfg.Locale, "LOCALE"
+	}
+	if err := lorem.SetLocales(spec); err != nil {
+		return configError("%v %v", name, err)
+	}
+	if err := lorem.SetPhoneFormat(cfg.PhoneFormat); err != nil {
+		return configError("PHONE_FORMAT %v", err)
+	}
+	return nil
+}
+
+// loads the text model named by TEXT_MODEL, the embedded one is used when it isn't set
+func useTextModel(cfg *config.Config) error {
+	if cfg.TextModel == "" {
+		return nil
+	}
+	m, err := lorem.LoadMarkov(cfg.TextModel)
+	if err != nil {
+		return configError("TEXT_MODEL %v", err)
+	}
+	lorem.SetMarkov(m)
+	return nil
+}
+
+// loads the config and logs in to Salesforce
+func connect() (*config.Config, *simpleforce.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, c, nil
+}
+
+// loads the config from the environment and checks we have what we need to log in
+func loadConfig() (*config.Config, error) {
+	cfg := config.NewConfig()
+	if err := cfg.SF.Check(); err != nil {
+		return nil, &exitError{code: exitConfig, err: err}
+	}
+	return cfg, nil
+}
+
+func login(cfg *config.Config) (*simpleforce.Client, error) {
+	c, err := sforce.NewRestClient(&cfg.SF)
+	if err != nil {
+		return nil, &exitError{code: exitAuth, err: fmt.Errorf("unable to log in to %v as %v : %v", cfg.SF.LoginUrl, cfg.SF.Username, err)}
+	}
+	return c, nil
+}
+
+// returns nil, a partial failure or a total failure depending on how many of the units of work failed
+func summarise(errs []error, total int) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	err := errors.Join(errs...)
+	if len(errs) < total {
+		return partialError(err)
+	}
+	for _, e := range errs {
+		if exitCode(e) == exitPartial {
+			return partialError(err)
+		}
+	}
+	return err
+}
@@ -1,20 +1,61 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 type Config struct {
 	SF             SFConfig
+	Source         SFConfig // the org the copy command reads from, SF is the target
 	Mockaroo       MockarooConfig
+	Lookups        LookupConfig
+	Correlations   map[string]string // Object.Field to an expression on a parent field
+	Dates          []string          // Object:constraint entries for generated dates
+	Validate       string            // off, warn or strict checking of CSVs before upload
+	Unique         UniqueConfig
+	Staging        StagingConfig
+	Mask           MaskConfig
+	Rules          string // a json file of generation rules keyed by object and field
+	Locale         string // the locale, or weighted locales, names and addresses are made in
+	TextModel      string // a text model saved by train-text, descriptions and comments are made from it
+	PhoneFormat    string // national or e164
 	ModifyWithNull bool
 }
 type MockarooConfig struct {
 	Key     string
 	DataDir string
+	Backend string // mockaroo (the default) calls the api, native generates the data locally
 }
+
+// settings for populating reference fields, keyed by Object.Field
+type LookupConfig struct {
+	Distributions map[string]string
+	Filters       map[string]string // where clause, or @name of a pool
+	Pools         map[string]string // named soql queries returning a single Id column
+}
+
+// settings for generating values of unique and external Id fields
+type UniqueConfig struct {
+	Patterns map[string]string // Object.Field to a pattern like ACC-{000000}
+	CheckOrg bool              // start sequences past the values already in the org
+}
+
+// where to keep the data that passes through a run, staging is off without a path
+type StagingConfig struct {
+	Path  string // the SQLite database file
+	RunId string // tags everything saved, defaults to the start time
+}
+
+// settings for masking the data the update command queries
+type MaskConfig struct {
+	Key      string            // the HMAC key, the same key gives the same fakes
+	Policies map[string]string // Object.Field (or *.Field) to mask, keep, null or shuffle
+}
+
 type SFConfig struct {
 	Username    string
 	Password    string
@@ -40,14 +81,171 @@ func NewConfig() *Config {
 			Queries:     getEnvStringArray("QUERIES", ";"),
 			SfBatchSize: getEnvInt("SF_BATCH_SIZE", 200),
 		},
+		Source: SFConfig{
+			Username:   getEnv("SOURCE_SF_USER", ""),
+			Password:   getEnv("SOURCE_SF_PASS", ""),
+			Token:      getEnv("SOURCE_SF_TOKEN", ""),
+			LoginUrl:   getEnv("SOURCE_SF_ENDPOINT", ""),
+			ApiVersion: getEnvFloat("SOURCE_SF_API_VERSION", 0),
+		},
 		Mockaroo: MockarooConfig{
 			Key:     getEnv("MOCKAROO_KEY", ""),
 			DataDir: getEnv("MOCKAROO_DATA_DIR", ""),
+			Backend: getEnv("GENERATOR", ""),
+		},
+		Lookups: LookupConfig{
+			Distributions: getEnvMap("LOOKUP_DISTRIBUTIONS", ";"),
+			Filters:       getEnvMap("LOOKUP_FILTERS", ";"),
+			Pools:         getEnvMap("LOOKUP_POOLS", ";"),
+		},
+		Correlations: getEnvMap("CORRELATIONS", ";"),
+		Dates:        getEnvStringArray("DATE_CONSTRAINTS", ";"),
+		Validate:     getEnv("VALIDATE_UPLOADS", "warn"),
+		Unique: UniqueConfig{
+			Patterns: getEnvMap("UNIQUE_PATTERNS", ";"),
+			CheckOrg: getEnvBool("UNIQUE_CHECK_ORG", false),
 		},
+		Staging: StagingConfig{
+			Path:  getEnv("STAGING_DB", ""),
+			RunId: getEnv("STAGING_RUN_ID", ""),
+		},
+		Mask: MaskConfig{
+			Key:      getEnv("MASK_KEY", ""),
+			Policies: getEnvMap("MASK_POLICIES", ";"),
+		},
+		Rules:          getEnv("GENERATION_RULES", ""),
+		Locale:         getEnv("LOCALE", ""),
+		TextModel:      getEnv("TEXT_MODEL", ""),
+		PhoneFormat:    getEnv("PHONE_FORMAT", ""),
 		ModifyWithNull: getEnvBool("MODIFY_WITH_NULL", false),
 	}
 }
 
+// returns an error naming any of the settings needed to log in to Salesforce that are missing
+func (s SFConfig) Check() error {
+	return missing(map[string]string{"SF_USER": s.Username, "SF_PASS": s.Password, "SF_ENDPOINT": s.LoginUrl})
+}
+
+// returns the settings for the source org with the api version and batch size of the target
+// when they aren't set, or an error naming the settings that are missing
+func (c *Config) SourceOrg() (SFConfig, error) {
+	src := c.Source
+	if src.ApiVersion == 0 {
+		src.ApiVersion = c.SF.ApiVersion
+	}
+	if src.SfBatchSize == 0 {
+		src.SfBatchSize = c.SF.SfBatchSize
+	}
+	return src, missing(map[string]string{"SOURCE_SF_USER": src.Username, "SOURCE_SF_PASS": src.Password, "SOURCE_SF_ENDPOINT": src.LoginUrl})
+}
+
+// returns an error if there is no key to call Mockaroo with, the native generator doesn't need one
+func (m MockarooConfig) Check() error {
+	switch strings.ToLower(m.Backend) {
+	case "", "mockaroo":
+		return missing(map[string]string{"MOCKAROO_KEY": m.Key})
+	case "native":
+		return nil
+	}
+	return fmt.Errorf("GENERATOR must be mockaroo or native, not %v", m.Backend)
+}
+
+// true if the data is generated locally rather than by the Mockaroo api
+func (m MockarooConfig) Native() bool {
+	return strings.EqualFold(m.Backend, "native")
+}
+
+func missing(settings map[string]string) error {
+	var names []string
+	for k, v := range settings {
+		if strings.TrimSpace(v) == "" {
+			names = append(names, k)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%v not set, add to the environment or the .env file", strings.Join(names, ", "))
+}
+
+// returns the unique value pattern configured for obj.field, empty if there isn't one
+func (u UniqueConfig) PatternFor(obj string, field string) string {
+	return getKeyed(u.Patterns, obj, field)
+}
+
+// returns the mask policy configured for obj.field, or for the field on every object, empty if there isn't one
+func (m MaskConfig) PolicyFor(obj string, field string) string {
+	if p := getKeyed(m.Policies, obj, field); p != "" {
+		return p
+	}
+	return getKeyed(m.Policies, "*", field)
+}
+
+// returns an error if there is no key to mask with
+func (m MaskConfig) Check() error {
+	return missing(map[string]string{"MASK_KEY": m.Key})
+}
+
+// returns the correlation rules for obj keyed by field name
+func (c *Config) CorrelationsFor(obj string) map[string]string {
+	return getForObject(c.Correlations, obj)
+}
+
+// returns the entries of an Object.Field keyed map that belong to obj, keyed by field
+func getForObject(m map[string]string, obj string) map[string]string {
+	rules := make(map[string]string)
+	for k, v := range m {
+		o, field, ok := strings.Cut(k, ".")
+		if ok && strings.EqualFold(o, obj) {
+			rules[field] = v
+		}
+	}
+	return rules
+}
+
+// returns the distribution spec configured for obj.field, empty if there isn't one
+func (l LookupConfig) DistributionFor(obj string, field string) string {
+	return getKeyed(l.Distributions, obj, field)
+}
+
+// returns the candidate filter configured for obj.field, empty if there isn't one
+func (l LookupConfig) FilterFor(obj string, field string) string {
+	return getKeyed(l.Filters, obj, field)
+}
+
+// sets the candidate filter for obj.field unless one has already been configured
+func (l *LookupConfig) SetDefaultFilter(obj string, field string, filter string) {
+	if l.FilterFor(obj, field) != "" {
+		return
+	}
+	if l.Filters == nil {
+		l.Filters = make(map[string]string)
+	}
+	l.Filters[fmt.Sprintf("%v.%v", obj, field)] = filter
+}
+
+// returns the query saved under name in LOOKUP_POOLS, empty if there isn't one
+func (l LookupConfig) PoolQuery(name string) string {
+	for k, v := range l.Pools {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// case insensitive lookup of an Object.Field key
+func getKeyed(m map[string]string, obj string, field string) string {
+	key := fmt.Sprintf("%v.%v", obj, field)
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
 // get string environment variable
 func getEnv(key string, defaultVal string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -76,6 +274,24 @@ func getEnvStringArray(key string, separator string) []string {
 	return strings.Split(s, separator)
 }
 
+// returns nil if key not found
+// reads entries of key=value split on the separator
+func getEnvMap(key string, separator string) map[string]string {
+	s := getEnv(key, "")
+	if s == "" {
+		return nil
+	}
+	m := make(map[string]string)
+	for _, entry := range strings.Split(s, separator) {
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m
+}
+
 // returns an integer value for the key
 func getEnvInt(key string, defaultVal int) int {
 	s := getEnv(key, "")
@@ -34,6 +34,7 @@ func TestGetConfig(t *testing.T) {
 			SfBatchSize: 200,
 			Queries:     q,
 		},
+		Validate: "warn",
 	}
 	log.Printf("%v", cfg)
 	log.Printf("%v", testCfg)
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/mapping"
+	"github.com/troysellers/go-modifier/orgcopy"
+	"github.com/troysellers/go-modifier/sforce"
+	"github.com/troysellers/go-modifier/staging"
+)
+
+func init() {
+	register(command{name: "copy", summary: "copy records from the SOURCE_SF_* org to the SF_* org, remapping lookups", run: copyCommand})
+}
+
+// queries the objects from the source org and loads them into the target, parents first
+func copyCommand(args []string) error {
+	fs := newFlagSet("copy", "-objects Account,Contact [-where \"Account=Industry = 'Energy'\"] [-limit n] [-missing drop|fail] [-mapping Obj=file] [-idmap file] [-dryrun]")
+	var objects = fs.String("objects", "", "a comma separated list of the objects to copy")
+	var wheres stringList
+	fs.Var(&wheres, "where", "a where clause for one object written Obj=clause, can be given more than once")
+	var limit = fs.Int("limit", 0, "copy at most this many records of each object, 0 for all")
+	var missing = fs.String("missing", "drop", "what to do with source fields the target can't create, drop the column or fail")
+	var mappings stringList
+	fs.Var(&mappings, "mapping", "a .json or .sdl mapping for one object written Obj=file, can be given more than once")
+	var idMapFile = fs.String("idmap", "", "the file the source to target Id map is kept in, it is only used between the orgs it was made for. Defaults to copy-idmap.json in the data directory")
+	var dryRun = fs.Bool("dryrun", false, "write the CSVs that would be loaded but don't load them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var objs []string
+	for _, o := range strings.Split(*objects, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			objs = append(objs, o)
+		}
+	}
+	if len(objs) == 0 {
+		return configError("-objects is required")
+	}
+	if *missing != "drop" && *missing != "fail" {
+		return configError("-missing must be drop or fail")
+	}
+	if *limit < 0 {
+		return configError("-limit can't be negative")
+	}
+	where, err := perObject(wheres, objs, "-where")
+	if err != nil {
+		return err
+	}
+	mappingFor, err := perObject(mappings, objs, "-mapping")
+	if err != nil {
+		return err
+	}
+	for _, m := range mappingFor {
+		if _, err := mapping.Load(m); err != nil {
+			return configError("%v", err)
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	src, err := cfg.SourceOrg()
+	if err != nil {
+		return configError("%v", err)
+	}
+	if *idMapFile == "" {
+		if *idMapFile, err = file.BuildFilePath("copy-idmap.json", cfg); err != nil {
+			return err
+		}
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return err
+	}
+	sc, err := sforce.NewRestClient(&src)
+	if err != nil {
+		return &exitError{code: exitAuth, err: fmt.Errorf("unable to log in to the source org %v as %v : %v", src.LoginUrl, src.Username, err)}
+	}
+	// the Id map is only good for the orgs it was made between
+	srcOrg, err := sforce.OrgId(sc)
+	if err != nil {
+		return fmt.Errorf("source org : %v", err)
+	}
+	targetOrg, err := sforce.OrgId(c)
+	if err != nil {
+		return fmt.Errorf("target org : %v", err)
+	}
+	ids, err := orgcopy.OpenIdMap(*idMapFile, srcOrg, targetOrg)
+	if err != nil {
+		return configError("%v", err)
+	}
+	// the source calls use the source settings, everything else (data dir, staging) is shared
+	srcCfg := *cfg
+	srcCfg.SF = src
+
+	cp := &copier{
+		cfg: cfg, srcCfg: &srcCfg, target: c, source: sc, ids: ids,
+		where: where, mapping: mappingFor, limit: *limit, dropMissing: *missing == "drop", dryRun: *dryRun,
+		srcMeta: make(map[string]*simpleforce.SObjectMeta), targetMeta: make(map[string]*simpleforce.SObjectMeta),
+		inSet: make(map[string]bool),
+	}
+	deps := make(map[string][]string)
+	for _, o := range objs {
+		if err := cp.describe(o); err != nil {
+			return err
+		}
+		cp.inSet[strings.ToLower(o)] = true
+		for _, to := range cp.lookups(o) {
+			deps[o] = append(deps[o], to...)
+		}
+	}
+	if err := cp.mapRecordTypes(objs); err != nil {
+		log.Printf("Unable to match record types between the orgs, RecordTypeId will be left blank : %v", err)
+	}
+
+	order := orgcopy.Order(objs, deps)
+	log.Printf("Copying %v", strings.Join(order, ", "))
+	var errs []error
+	deferred := make(map[string][]orgcopy.Deferred)
+	for _, o := range order {
+		d, err := cp.copyObject(o)
+		deferred[o] = d
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%v] %w", o, err))
+		}
+		if err := ids.Save(); err != nil {
+			return err
+		}
+	}
+	if err := cp.setDeferred(order, deferred); err != nil {
+		errs = append(errs, err)
+	}
+	return summarise(errs, len(order))
+}
+
+// splits Obj=value flags into a map keyed by the object as it was named in -objects
+func perObject(values []string, objs []string, name string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, v := range values {
+		o, val, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(val) == "" {
+			return nil, configError("%v is written Obj=value, got %v", name, v)
+		}
+		found := false
+		for _, obj := range objs {
+			if strings.EqualFold(obj, strings.TrimSpace(o)) {
+				m[obj] = strings.TrimSpace(val)
+				found = true
+			}
+		}
+		if !found {
+			return nil, configError("%v names %v which isn't in -objects", name, o)
+		}
+	}
+	return m, nil
+}
+
+type copier struct {
+	cfg         *config.Config
+	srcCfg      *config.Config
+	target      *simpleforce.Client
+	source      *simpleforce.Client
+	ids         *orgcopy.IdMap
+	where       map[string]string
+	mapping     map[string]string
+	limit       int
+	dropMissing bool
+	dryRun      bool
+	srcMeta     map[string]*simpleforce.SObjectMeta
+	targetMeta  map[string]*simpleforce.SObjectMeta
+	inSet       map[string]bool
+}
+
+func (cp *copier) describe(obj string) error {
+	if cp.srcMeta[obj] = cp.source.SObject(obj).Describe(); cp.srcMeta[obj] == nil {
+		return fmt.Errorf("unable to describe %v in the source org", obj)
+	}
+	if cp.targetMeta[obj] = cp.target.SObject(obj).Describe(); cp.targetMeta[obj] == nil {
+		return fmt.Errorf("unable to describe %v in the target org", obj)
+	}
+	return nil
+}
+
+// returns each createable lookup of the object in the target and the objects it can reference
+func (cp *copier) lookups(obj string) map[string][]string {
+	refs := make(map[string][]string)
+	for _, f := range metaFields(cp.targetMeta[obj]) {
+		if f["type"] != "reference" || f["createable"] != true {
+			continue
+		}
+		rt, _ := f["referenceTo"].([]interface{})
+		for _, r := range rt {
+			refs[f["name"].(string)] = append(refs[f["name"].(string)], fmt.Sprintf("%v", r))
+		}
+	}
+	return refs
+}
+
+// the fields to query from the source, Id and everything that can be created.
+// Compound and base64 fields can't be read by the bulk api.
+func (cp *copier) sourceFields(obj string) []string {
+	fields := []string{"Id"}
+	for _, f := range metaFields(cp.srcMeta[obj]) {
+		if f["createable"] != true {
+			continue
+		}
+		switch f["type"] {
+		case "address", "location", "base64":
+			continue
+		}
+		fields = append(fields, f["name"].(string))
+	}
+	return fields
+}
+
+// adds the record types of the copied objects to the Id map, matched on their developer name
+func (cp *copier) mapRecordTypes(objs []string) error {
+	var in []string
+	for _, o := range objs {
+		in = append(in, fmt.Sprintf("'%v'", o))
+	}
+	q := fmt.Sprintf("select Id, SobjectType, DeveloperName from RecordType where SobjectType in (%v)", strings.Join(in, ","))
+	srcTypes, err := recordTypes(cp.source, q)
+	if err != nil {
+		return err
+	}
+	targetTypes, err := recordTypes(cp.target, q)
+	if err != nil {
+		return err
+	}
+	for k, id := range srcTypes {
+		if tid, ok := targetTypes[k]; ok {
+			cp.ids.Set(id, tid)
+		} else {
+			log.Printf("Record type %v isn't in the target org", k)
+		}
+	}
+	return nil
+}
+
+// returns the record type Ids keyed by Object.DeveloperName
+func recordTypes(c *simpleforce.Client, q string) (map[string]string, error) {
+	types := make(map[string]string)
+	for q != "" {
+		res, err := c.Query(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res.Records {
+			types[strings.ToLower(r.StringField("SobjectType")+"."+r.StringField("DeveloperName"))] = r.ID()
+		}
+		q = ""
+		if !res.Done {
+			q = res.NextRecordsURL
+		}
+	}
+	return types, nil
+}
+
+// queries the object from the source, rewrites the lookups and upserts it into the target.
+// Returns the lookups to set once the rest of the objects are loaded.
+func (cp *copier) copyObject(obj string) ([]orgcopy.Deferred, error) {
+	q := fmt.Sprintf("select %v from %v", strings.Join(cp.sourceFields(obj), ", "), obj)
+	if w, ok := cp.where[obj]; ok {
+		q += " where " + w
+	}
+	if cp.limit > 0 {
+		q += fmt.Sprintf(" limit %d", cp.limit)
+	}
+	qj, err := sforce.GetBulkQuery(cp.srcCfg, cp.source, q)
+	if err != nil {
+		return nil, err
+	}
+	data := qj.QueryData
+	if len(data) < 2 {
+		log.Printf("No %v records to copy", obj)
+		return nil, nil
+	}
+	if m, ok := cp.mapping[obj]; ok {
+		if data, err = mapWithId(data, m); err != nil {
+			return nil, err
+		}
+	}
+	if data, err = cp.targetColumns(obj, data); err != nil {
+		return nil, err
+	}
+	batch, err := orgcopy.Rewrite(data, cp.lookups(obj), cp.inSet, cp.ids)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Unresolved > 0 {
+		log.Printf("%d %v lookups reference records that aren't being copied, they have been left blank", batch.Unresolved, obj)
+	}
+	path, err := file.BuildFilePath(fmt.Sprintf("%v-copy.csv", obj), cp.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteCsv(path, batch.Data); err != nil {
+		return nil, err
+	}
+	if cp.dryRun {
+		log.Printf("Wrote %d %v records to %v", len(batch.Data)-1, obj, path)
+		return nil, nil
+	}
+	uj, err := sforce.RunIngestJob(cp.cfg, cp.target, path, obj, "upsert", "Id")
+	var failed *sforce.FailedRecordsError
+	if err != nil && !errors.As(err, &failed) {
+		return nil, err
+	}
+	results, rerr := uj.SuccessfulResults()
+	if rerr != nil {
+		return nil, fmt.Errorf("unable to fetch the Ids of the copied records, they will be inserted again if copied again : %v", rerr)
+	}
+	matched := staging.MatchIds(batch.Data, results)
+	for _, m := range matched {
+		cp.ids.Set(batch.OldIds[m.RowNum-1], m.NewId)
+	}
+	log.Printf("Copied %d %v records", len(matched), obj)
+	return batch.Deferred, err
+}
+
+// runs the data through the mapping, the Id column is kept as it is so records can be matched
+func mapWithId(data [][]string, mappingFile string) ([][]string, error) {
+	idCol := -1
+	for i, h := range data[0] {
+		if strings.EqualFold(h, "Id") {
+			idCol = i
+		}
+	}
+	if idCol < 0 {
+		return nil, fmt.Errorf("the data has no Id column to match the records with")
+	}
+	m, err := mapping.Load(mappingFile)
+	if err != nil {
+		return nil, err
+	}
+	var rest, idVals [][]string
+	for _, row := range data {
+		idVals = append(idVals, []string{row[idCol]})
+		rest = append(rest, append(append([]string{}, row[:idCol]...), row[idCol+1:]...))
+	}
+	mapped, err := m.Apply(rest)
+	if err != nil {
+		return nil, fmt.Errorf("mapping with %v : %v", mappingFile, err)
+	}
+	for i := range mapped {
+		mapped[i] = append(idVals[i], mapped[i]...)
+	}
+	return mapped, nil
+}
+
+// drops (or fails on) the columns the target object can't create
+func (cp *copier) targetColumns(obj string, data [][]string) ([][]string, error) {
+	createable := map[string]bool{"id": true}
+	for _, f := range metaFields(cp.targetMeta[obj]) {
+		if f["createable"] == true {
+			createable[strings.ToLower(f["name"].(string))] = true
+		}
+	}
+	var keep []int
+	var dropped []string
+	for i, h := range data[0] {
+		if createable[strings.ToLower(h)] {
+			keep = append(keep, i)
+		} else {
+			dropped = append(dropped, h)
+		}
+	}
+	if len(dropped) == 0 {
+		return data, nil
+	}
+	if !cp.dropMissing {
+		return nil, fmt.Errorf("%v can't be created in the target org", strings.Join(dropped, ", "))
+	}
+	log.Printf("Dropping %v from %v, the target org can't create them", strings.Join(dropped, ", "), obj)
+	out := make([][]string, len(data))
+	for r, row := range data {
+		for _, i := range keep {
+			out[r] = append(out[r], row[i])
+		}
+	}
+	return out, nil
+}
+
+// sets the lookups to records that were loaded after the records that reference them
+func (cp *copier) setDeferred(order []string, deferred map[string][]orgcopy.Deferred) error {
+	if cp.dryRun {
+		return nil
+	}
+	var errs []error
+	for _, obj := range order {
+		if len(deferred[obj]) == 0 {
+			continue
+		}
+		rows, unresolved := orgcopy.ResolveDeferred(deferred[obj], cp.ids)
+		if unresolved > 0 {
+			log.Printf("%d %v lookups reference records that failed to copy, they have been left blank", unresolved, obj)
+		}
+		if len(rows) < 2 {
+			continue
+		}
+		path, err := file.BuildFilePath(fmt.Sprintf("%v-copy-lookups.csv", obj), cp.cfg)
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteCsv(path, rows); err != nil {
+			return err
+		}
+		log.Printf("Setting %d %v lookups to records loaded after them", len(rows)-1, obj)
+		if err := sforce.IngestCSV(cp.cfg, cp.target, path, obj, "update", ""); err != nil {
+			errs = append(errs, fmt.Errorf("[%v lookups] %w", obj, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func metaFields(meta *simpleforce.SObjectMeta) []map[string]interface{} {
+	var fields []map[string]interface{}
+	if meta == nil {
+		return fields
+	}
+	fs, _ := (*meta)["fields"].([]interface{})
+	for _, f := range fs {
+		if field, ok := f.(map[string]interface{}); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
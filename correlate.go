@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/lookup"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+// sets fields from the parent records the lookups point at, using the CORRELATIONS rules for sobj.
+// This runs once the lookup columns in f have been populated.
+func correlateParents(cfg *config.Config, f string, sobj *simpleforce.SObjectMeta, c *simpleforce.Client) error {
+	name := (*sobj)["name"].(string)
+	rules, err := lookup.ParseCorrelations(cfg.CorrelationsFor(name))
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	// group the rules by lookup so each parent object is only queried once
+	var lookups []string
+	byLookup := make(map[string][]lookup.Correlation)
+	for _, r := range rules {
+		if _, ok := byLookup[r.Lookup]; !ok {
+			lookups = append(lookups, r.Lookup)
+		}
+		byLookup[r.Lookup] = append(byLookup[r.Lookup], r)
+	}
+
+	for _, l := range lookups {
+		parentObj, err := referencedObject(sobj, l)
+		if err != nil {
+			return err
+		}
+		parentIds, err := file.GetColumn(f, l)
+		if err != nil {
+			return err
+		}
+		// rules can read the same parent field, it is only queried once
+		var parentFields []string
+		seen := make(map[string]bool)
+		for _, r := range byLookup[l] {
+			if !seen[r.ParentField] {
+				seen[r.ParentField] = true
+				parentFields = append(parentFields, r.ParentField)
+			}
+		}
+		parents, err := sforce.GetRecords(c, parentObj, parentIds, parentFields)
+		if err != nil {
+			return err
+		}
+		for _, r := range byLookup[l] {
+			values, err := file.GetColumn(f, r.Field)
+			if err != nil {
+				return err
+			}
+			for i, id := range parentIds {
+				if p, ok := parents[id]; ok {
+					values[i] = r.Apply(values[i], p[r.ParentField])
+				}
+			}
+			if err := file.SetColumn(f, r.Field, values); err != nil {
+				return err
+			}
+			log.Printf("Correlated %v.%v with %v(%v.%v)", name, r.Field, r.Func, l, r.ParentField)
+		}
+	}
+	return nil
+}
+
+// returns the object a lookup field on sobj points at
+func referencedObject(sobj *simpleforce.SObjectMeta, fieldName string) (string, error) {
+	if strings.EqualFold(fieldName, "OwnerId") {
+		return "User", nil
+	}
+	for _, f := range (*sobj)["fields"].([]interface{}) {
+		field := f.(map[string]interface{})
+		if !strings.EqualFold(field["name"].(string), fieldName) {
+			continue
+		}
+		rt, _ := field["referenceTo"].([]interface{})
+		if len(rt) != 1 {
+			return "", fmt.Errorf("%v.%v must reference exactly one object to be correlated, it references %v", (*sobj)["name"], fieldName, rt)
+		}
+		return rt[0].(string), nil
+	}
+	return "", fmt.Errorf("%v has no lookup field %v", (*sobj)["name"], fieldName)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/lookup"
+	"github.com/troysellers/go-modifier/mockaroo"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+// how many children share each parent when we have to create parents for a required lookup
+const childrenPerCreatedParent int = 5
+
+// what to create and how to relate it
+type createOptions struct {
+	obj         string
+	count       int
+	references  bool              // populate the reference fields from records in the org
+	fetchOnly   bool              // fetch and merge the data but don't send it to Salesforce
+	whoTargets  []lookup.Weighted // activity WhoId targets
+	whatTargets []lookup.Weighted // activity WhatId targets
+	personRatio float64           // share of records that are, or relate to, person accounts
+	savedSchema string            // a schema saved in mockaroo to fetch instead of the one built from describe
+	mergeLocal  bool              // add the fields the saved schema doesn't have
+	creating    []string          // the objects whose records are being created for this one, outermost first
+}
+
+func init() {
+	register(command{name: "create", summary: "create records from Mockaroo data", run: createCommand})
+}
+
+// parses and checks the create flags then creates the records
+func createCommand(args []string) error {
+	fs := newFlagSet("create", "-obj <object> [-count 10] [flags]")
+	var count = fs.Int("count", 10, "how many records to get from mockaroo")
+	var obj = fs.String("obj", "", "which salesforce object you want to create")
+	var references = fs.Bool("references", true, "populate reference fields with random records from the Salesforce org")
+	var fetchOnly = fs.Bool("fetch", false, "fetch and merge mockaroo data but don't send it to Salesforce")
+	var whoObj = fs.String("who", "", "if creating activities (tasks/events) the weighted who objects, e.g. Contact:80,Lead:20")
+	var whatObj = fs.String("what", "", "if creating activities (tasks/events) the weighted what objects (any activity enabled obj), e.g. Account:50,Opportunity:30,Case:20")
+	var personAccounts = fs.Bool("personaccounts", false, "create person accounts (or relate other objects to person accounts). Same as -personratio 1")
+	var personRatio = fs.Float64("personratio", 0, "share of the records (0 to 1) that are, or are related to, person accounts. The rest are business accounts")
+	var seed, date = seedFlag(fs)
+	var locale = localeFlag(fs)
+	var savedSchema = fs.String("schema", "", "the name of a schema saved in the Mockaroo project to fetch instead of the one built from describe")
+	var mergeLocal = fs.Bool("merge", false, "with -schema, also generate the fields the saved schema doesn't have")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *obj == "" {
+		return configError("-obj is required")
+	}
+	if *count < 1 {
+		return configError("-count must be at least 1")
+	}
+	if *personRatio < 0 || *personRatio > 1 {
+		return configError("-personratio must be between 0 and 1")
+	}
+	if strings.EqualFold(*obj, "personaccount") {
+		*obj = "Account"
+		*personAccounts = true
+	}
+	if *personAccounts {
+		*personRatio = 1
+	}
+	if *mergeLocal && *savedSchema == "" {
+		return configError("-merge needs -schema")
+	}
+	if *savedSchema != "" && *personRatio > 0 && strings.EqualFold(*obj, "account") {
+		return configError("-schema can't make person accounts, leave out -personaccounts and -personratio")
+	}
+	whoTargets, whatTargets, err := parseActivityTargets(*whoObj, *whatObj)
+	if err != nil {
+		return configError("%v", err)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Mockaroo.Check(); err != nil {
+		return configError("%v", err)
+	}
+	if *savedSchema != "" && cfg.Mockaroo.Key == "" {
+		return configError("MOCKAROO_KEY is needed to fetch the saved schema %v", *savedSchema)
+	}
+	if err := useLocales(*locale, cfg); err != nil {
+		return err
+	}
+	if err := useTextModel(cfg); err != nil {
+		return err
+	}
+	if err := useSeed(*seed, *date); err != nil {
+		return err
+	}
+	if *seed != 0 && !cfg.Mockaroo.Native() {
+		log.Printf("Data from Mockaroo isn't seeded, set GENERATOR=native for a run that can be repeated")
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return err
+	}
+	// get a syncMap to store any downloaded Ids so we only do this once.
+	var objIds sync.Map
+	return createRecords(cfg, c, &objIds, createOptions{
+		obj:         *obj,
+		count:       *count,
+		references:  *references,
+		fetchOnly:   *fetchOnly,
+		whoTargets:  whoTargets,
+		whatTargets: whatTargets,
+		personRatio: *personRatio,
+		savedSchema: *savedSchema,
+		mergeLocal:  *mergeLocal,
+	})
+}
+
+// fetches mockaroo data for the object, populates the reference fields and uploads it.
+func createRecords(cfg *config.Config, c *simpleforce.Client, objIds *sync.Map, opts createOptions) error {
+	log.Printf("Creating for %v\n", opts.obj)
+	if opts.personRatio > 0 && strings.EqualFold(opts.obj, "contact") {
+		return fmt.Errorf("if you wish to create Contacts that are Person Accounts you need to specify account as the object")
+	}
+	hasPersonAccounts, personRecordType, err := setupPersonAccounts(cfg, c, opts.obj, opts.personRatio)
+	if err != nil {
+		return err
+	}
+	o := c.SObject(opts.obj)
+	mr := &mockaroo.MockarooRequest{
+		SObject:            o.Describe(),
+		Cfg:                cfg,
+		Count:              opts.count,
+		PersonAccountRatio: opts.personRatio,
+		PersonRecordTypeId: personRecordType,
+		SavedSchema:        opts.savedSchema,
+		MergeLocal:         opts.mergeLocal,
+	}
+	if mr.SObject == nil {
+		return fmt.Errorf("unable to describe %v", opts.obj)
+	}
+
+	if err := mr.GetDataForObj(); err != nil {
+		return err
+	}
+	if err := fillUniqueValues(cfg, c, opts.obj, mr); err != nil {
+		return err
+	}
+
+	if opts.references {
+		fields := mr.Schema
+		var activity bool
+		for _, f := range fields {
+			field := f.GetField().SforceMeta
+			// Who and What are polymorphic, they are populated from the -who and -what targets below.
+			if field["relationshipName"] == "Who" || field["relationshipName"] == "What" {
+				activity = true
+				continue
+			}
+			// look for the relationship fields that have been included in the schema
+			if field["relationshipName"] != nil {
+				log.Println(f.GetField().Name)
+				// fetch all the possible Ids for this.
+				fieldName := field["name"].(string)
+				rt := field["referenceTo"].([]interface{})
+				var referenceTo string
+				if fieldName == "OwnerId" {
+					referenceTo = "User"
+				} else {
+					referenceTo = rt[0].(string)
+				}
+				if referenceTo != "" {
+					err := updateIds(cfg, mr.FilePath, opts.obj, referenceTo, fieldName, objIds, c)
+					// a required lookup (e.g. master-detail) with nothing to point at gets some new parents
+					if errors.Is(err, sforce.ErrNoCandidates) && sforce.IsRequired(field) {
+						log.Printf("There are no %v records for the required field %v, creating some", referenceTo, fieldName)
+						if err := createParents(cfg, c, objIds, referenceTo, opts); err != nil {
+							return err
+						}
+						err = updateIds(cfg, mr.FilePath, opts.obj, referenceTo, fieldName, objIds, c)
+					}
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if activity {
+			if err := updateActivityTargets(cfg, mr.FilePath, opts.obj, opts.whoTargets, opts.whatTargets, objIds, c); err != nil {
+				return err
+			}
+		}
+		if hasPersonAccounts && opts.personRatio > 0 && !strings.EqualFold(opts.obj, "account") {
+			if err := updatePersonAccountLookups(cfg, mr.FilePath, mr.Schema, opts.personRatio, objIds, c); err != nil {
+				return err
+			}
+		}
+		// now the parents are known, copy or derive fields from them
+		if err := correlateParents(cfg, mr.FilePath, mr.SObject, c); err != nil {
+			return err
+		}
+	} else if err := updateIds(cfg, mr.FilePath, opts.obj, "user", "ownerId", objIds, c); err != nil { // always update the owner
+		return err
+	}
+	if !opts.fetchOnly {
+		// write data into Salesforce
+		if err := sforce.UploadCSVToSalesforce(cfg, c, mr.FilePath, opts.obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mockaroo leaves unique and external Id text fields blank, fill them from the persisted sequences
+func fillUniqueValues(cfg *config.Config, c *simpleforce.Client, obj string, mr *mockaroo.MockarooRequest) error {
+	for _, f := range mr.Schema {
+		field := f.GetField().SforceMeta
+		if field == nil || !sforce.IsUniqueText(field) {
+			continue
+		}
+		rows, err := file.CountRows(mr.FilePath)
+		if err != nil || rows == 0 {
+			return err
+		}
+		values, err := sforce.NextUniqueValues(cfg, c, obj, field, rows)
+		if err != nil {
+			return err
+		}
+		if err := file.SetColumn(mr.FilePath, field["name"].(string), values); err != nil {
+			return err
+		}
+		log.Printf("%v.%v set to %v ... %v", obj, field["name"], values[0], values[len(values)-1])
+	}
+	return nil
+}
+
+// creates parent records for the children of child that need one, one parent for every few children.
+// A parent that is already being created (a required lookup to itself, or a cycle) can't be created
+// first and is an error, as is a fetch only run that would have to send the parents to the org.
+func createParents(cfg *config.Config, c *simpleforce.Client, objIds *sync.Map, obj string, child createOptions) error {
+	creating := append(append([]string(nil), child.creating...), child.obj)
+	for _, o := range creating {
+		if strings.EqualFold(o, obj) {
+			return fmt.Errorf("unable to create %v records for a required lookup, they need records of their own first : %v -> %v", obj, strings.Join(creating, " -> "), obj)
+		}
+	}
+	if child.fetchOnly {
+		return fmt.Errorf("there are no %v records for the %v to look up, -fetch doesn't create them in Salesforce", obj, child.obj)
+	}
+	count := child.count / childrenPerCreatedParent
+	if count < 1 {
+		count = 1
+	}
+	return createRecords(cfg, c, objIds, createOptions{
+		obj:        obj,
+		count:      count,
+		references: true,
+		creating:   creating,
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/mockaroo"
+)
+
+func init() {
+	register(command{name: "upload-dataset", summary: "upload a CSV to Mockaroo as a dataset saved schemas can use", run: uploadDatasetCommand})
+}
+
+// uploads a CSV as a mockaroo dataset, Salesforce isn't needed
+func uploadDatasetCommand(args []string) error {
+	fs := newFlagSet("upload-dataset", "-file <data.csv> [-name <dataset>]")
+	var path = fs.String("file", "", "the CSV to upload, with a header row")
+	var name = fs.String("name", "", "the name of the dataset in Mockaroo, defaults to the file name without .csv. An existing dataset of that name is replaced")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return configError("-file is required")
+	}
+	cfg := config.NewConfig()
+	if cfg.Mockaroo.Key == "" {
+		return configError("MOCKAROO_KEY is required to upload a dataset")
+	}
+	return mockaroo.UploadDataset(cfg.Mockaroo.Key, *name, *path)
+}
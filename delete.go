@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+func init() {
+	register(command{name: "delete", summary: "delete the records returned by a SOQL query", run: deleteCommand})
+}
+
+// queries the Ids to delete and writes them to <object>-delete.csv.
+// Nothing is deleted unless -confirm is given.
+func deleteCommand(args []string) error {
+	fs := newFlagSet("delete", "-soql \"select Id from ...\" [-confirm] [-hard]")
+	var soql = fs.String("soql", "", "a query returning the Ids of the records to delete")
+	var confirm = fs.Bool("confirm", false, "delete the records, without this the Ids are only written to file")
+	var hard = fs.Bool("hard", false, "hard delete, the records don't go to the recycle bin (needs the Bulk API Hard Delete permission)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *soql == "" {
+		return configError("-soql is required")
+	}
+	cfg, c, err := connect()
+	if err != nil {
+		return err
+	}
+	qj, err := sforce.GetBulkQuery(cfg, c, *soql)
+	if err != nil {
+		return err
+	}
+	if len(qj.QueryData) == 0 {
+		return fmt.Errorf("the query returned no header row")
+	}
+	idCol := -1
+	for i, h := range qj.QueryData[0] {
+		if h == "Id" {
+			idCol = i
+		}
+	}
+	if idCol < 0 {
+		return configError("the query must select Id")
+	}
+	ids := [][]string{{"Id"}}
+	for _, row := range qj.QueryData[1:] {
+		ids = append(ids, []string{row[idCol]})
+	}
+	obj := qj.BulkJob.Object
+	fPath, err := file.BuildFilePath(fmt.Sprintf("%v-delete.csv", obj), cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteCsv(fPath, ids); err != nil {
+		return err
+	}
+	if len(ids) == 1 {
+		log.Printf("No %v records to delete", obj)
+		return nil
+	}
+	if !*confirm {
+		log.Printf("%d %v records would be deleted, their Ids are in %v. Run again with -confirm to delete them", len(ids)-1, obj, fPath)
+		return nil
+	}
+	operation := "delete"
+	if *hard {
+		operation = "hardDelete"
+	}
+	return sforce.IngestCSV(cfg, c, fPath, obj, operation, "")
+}
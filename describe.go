@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+func init() {
+	register(command{name: "describe", summary: "list the fields of an object", run: describeCommand})
+}
+
+// prints the fields of an object as a table, or the whole describe as json
+func describeCommand(args []string) error {
+	fs := newFlagSet("describe", "-obj <object> [-required] [-json]")
+	var obj = fs.String("obj", "", "the object to describe")
+	var required = fs.Bool("required", false, "only list the fields that need a value on create")
+	var asJson = fs.Bool("json", false, "print the full describe as json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *obj == "" {
+		return configError("-obj is required")
+	}
+	_, c, err := connect()
+	if err != nil {
+		return err
+	}
+	meta := c.SObject(*obj).Describe()
+	if meta == nil {
+		return fmt.Errorf("unable to describe %v, check the name and your access to it", *obj)
+	}
+	if *asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(meta)
+	}
+	return writeDescribe(os.Stdout, meta, *required)
+}
+
+// writes a row for each field with its type, length and what can be done with it
+func writeDescribe(w io.Writer, meta *simpleforce.SObjectMeta, requiredOnly bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tTYPE\tLENGTH\tREQUIRED\tCREATE\tUPDATE\tREFERENCES")
+	for _, f := range (*meta)["fields"].([]interface{}) {
+		field := f.(map[string]interface{})
+		req := sforce.IsRequired(field)
+		if requiredOnly && !req {
+			continue
+		}
+		var refs []string
+		rt, _ := field["referenceTo"].([]interface{})
+		for _, r := range rt {
+			refs = append(refs, fmt.Sprintf("%v", r))
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			field["name"], describeType(field), field["length"], yes(req), yes(field["createable"].(bool)), yes(field["updateable"].(bool)), strings.Join(refs, ","))
+	}
+	return tw.Flush()
+}
+
+// adds the precision and scale to numbers
+func describeType(field map[string]interface{}) string {
+	t := field["type"].(string)
+	switch t {
+	case "currency", "double", "percent":
+		return fmt.Sprintf("%v(%v,%v)", t, field["precision"], field["scale"])
+	}
+	return t
+}
+
+func yes(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
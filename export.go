@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/export"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+func init() {
+	register(command{name: "export", summary: "write the results of SOQL queries to CSV, NDJSON or Parquet", run: exportCommand})
+}
+
+// a flag that can be given more than once
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ";")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// runs each query with the Bulk API and writes the typed results to -out
+func exportCommand(args []string) error {
+	fs := newFlagSet("export", "-soql \"select ...\" [-soql ...] [-out <file or dir>] [-format csv|ndjson|parquet] [-gzip]")
+	var queries stringList
+	fs.Var(&queries, "soql", "a query to export, can be given more than once. Defaults to the QUERIES setting")
+	var out = fs.String("out", "", "the file to write, or the directory for several queries. Defaults to <object>.<format> in the data directory")
+	var format = fs.String("format", "", "csv, ndjson or parquet. Defaults to the extension of -out, then csv")
+	var gz = fs.Bool("gzip", false, "gzip the file (parquet files compress their pages instead)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	f, err := export.Format(*format, *out)
+	if err != nil {
+		return configError("%v", err)
+	}
+	if strings.HasSuffix(*out, ".gz") {
+		*gz = true
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		for _, q := range cfg.SF.Queries {
+			if strings.TrimSpace(q) != "" {
+				queries = append(queries, q)
+			}
+		}
+	}
+	if len(queries) == 0 {
+		return configError("no queries to export, use -soql or set QUERIES")
+	}
+	dir := ""
+	if *out == "" || len(queries) > 1 {
+		if dir, err = exportDir(cfg, *out); err != nil {
+			return err
+		}
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	used := make(map[string]int)
+	for _, q := range queries {
+		qj, err := sforce.GetBulkQuery(cfg, c, q)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%v] %w", q, err))
+			continue
+		}
+		path := *out
+		if dir != "" {
+			name := qj.BulkJob.Object
+			if used[name]++; used[name] > 1 {
+				name = fmt.Sprintf("%v-%d", name, used[name])
+			}
+			path = filepath.Join(dir, name+export.Extension(f, *gz))
+		}
+		if err := exportQuery(qj, path, f, *gz); err != nil {
+			errs = append(errs, fmt.Errorf("[%v] %w", q, err))
+			continue
+		}
+		log.Printf("Exported %d %v records to %v", len(qj.QueryData)-1, qj.BulkJob.Object, path)
+	}
+	return summarise(errs, len(queries))
+}
+
+// writes the query results to path, typed by the describe of the queried object
+func exportQuery(qj sforce.QueryJob, path string, format string, gz bool) error {
+	if len(qj.QueryData) == 0 {
+		return fmt.Errorf("the query returned no header row")
+	}
+	var fields []interface{}
+	if qj.SFObjectMeta != nil {
+		fields, _ = (*qj.SFObjectMeta)["fields"].([]interface{})
+	}
+	w, err := export.NewWriter(path, format, export.Columns(qj.QueryData[0], fields), gz)
+	if err != nil {
+		return err
+	}
+	for i, row := range qj.QueryData[1:] {
+		if err := w.Write(row); err != nil {
+			w.Close()
+			return fmt.Errorf("row %d %v", i+1, err)
+		}
+	}
+	return w.Close()
+}
+
+// returns the directory to write the exports to, creating it if needed
+func exportDir(cfg *config.Config, out string) (string, error) {
+	if out == "" {
+		p, err := file.BuildFilePath("", cfg)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Clean(p), nil
+	}
+	if info, err := os.Stat(out); err == nil && !info.IsDir() {
+		return "", configError("%v is a file, -out must be a directory when exporting several queries", out)
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return "", err
+	}
+	return out, nil
+}
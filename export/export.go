@@ -0,0 +1,246 @@
+package export
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the formats rows can be written in
+const (
+	CSV     string = "csv"
+	NDJSON  string = "ndjson"
+	Parquet string = "parquet"
+)
+
+// a column of the query results and the kind of value it holds
+type Column struct {
+	Name string
+	Kind string // string, boolean, int, double, date or datetime
+}
+
+// writes rows of query results to a file
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// returns the columns of the header typed from the fields of the object's describe.
+// Columns that aren't fields of the object (e.g. Account.Name) are strings.
+func Columns(header []string, fields []interface{}) []Column {
+	kinds := make(map[string]string)
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		kinds[strings.ToLower(field["name"].(string))] = kind(field["type"].(string))
+	}
+	cols := make([]Column, len(header))
+	for i, h := range header {
+		cols[i] = Column{Name: h, Kind: kinds[strings.ToLower(h)]}
+		if cols[i].Kind == "" {
+			cols[i].Kind = "string"
+		}
+	}
+	return cols
+}
+
+// the kind of value a salesforce field type holds
+func kind(sfType string) string {
+	switch sfType {
+	case "boolean":
+		return "boolean"
+	case "int", "long":
+		return "int"
+	case "double", "currency", "percent":
+		return "double"
+	case "date":
+		return "date"
+	case "datetime":
+		return "datetime"
+	}
+	return "string"
+}
+
+// returns the format named, or the one implied by the path's extension (ignoring a .gz)
+func Format(format string, path string) (string, error) {
+	if format == "" {
+		ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".gz")))
+		switch ext {
+		case ".ndjson", ".jsonl", ".json":
+			return NDJSON, nil
+		case ".parquet":
+			return Parquet, nil
+		}
+		return CSV, nil
+	}
+	switch strings.ToLower(format) {
+	case CSV:
+		return CSV, nil
+	case NDJSON, "jsonl":
+		return NDJSON, nil
+	case Parquet:
+		return Parquet, nil
+	}
+	return "", fmt.Errorf("unknown format %v, use csv, ndjson or parquet", format)
+}
+
+// returns the extension files of the format are written with
+func Extension(format string, gz bool) string {
+	ext := "." + format
+	if gz && format != Parquet {
+		ext += ".gz"
+	}
+	return ext
+}
+
+// creates the file at path and returns a writer for the format.
+// gz compresses the file, parquet files compress their pages with gzip instead.
+func NewWriter(path string, format string, cols []Column, gz bool) (Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if format == Parquet {
+		w, err := newParquetWriter(f, cols, gz)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return w, nil
+	}
+	var out io.Writer = f
+	closers := []io.Closer{f}
+	if gz {
+		z := gzip.NewWriter(f)
+		out = z
+		closers = []io.Closer{z, f}
+	}
+	switch format {
+	case NDJSON:
+		return &ndjsonWriter{out: out, cols: cols, closers: closers}, nil
+	case CSV:
+		w := csv.NewWriter(out)
+		header := make([]string, len(cols))
+		for i, c := range cols {
+			header[i] = c.Name
+		}
+		if err := w.Write(header); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &csvWriter{w: w, closers: closers}, nil
+	}
+	f.Close()
+	return nil, fmt.Errorf("unknown format %v", format)
+}
+
+// converts a value from the bulk api to the column's kind, nil when it is blank
+func Typed(c Column, v string) (interface{}, error) {
+	if v == "" {
+		return nil, nil
+	}
+	switch c.Kind {
+	case "boolean":
+		return strconv.ParseBool(v)
+	case "int":
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			// ints sometimes come back as 10.0
+			f, ferr := strconv.ParseFloat(v, 64)
+			if ferr != nil || f != math.Trunc(f) {
+				return nil, err
+			}
+			return int64(f), nil
+		}
+		return n, nil
+	case "double":
+		return strconv.ParseFloat(v, 64)
+	case "date":
+		return time.Parse("2006-01-02", v)
+	case "datetime":
+		for _, l := range []string{"2006-01-02T15:04:05.000Z0700", "2006-01-02T15:04:05.000Z07:00", time.RFC3339} {
+			if t, err := time.Parse(l, v); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return nil, fmt.Errorf("unable to read %v as a datetime", v)
+	}
+	return v, nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	closers []io.Closer
+}
+
+func (w *csvWriter) Write(row []string) error {
+	return w.w.Write(row)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		return err
+	}
+	return closeAll(w.closers)
+}
+
+// writes a json object per line with the keys in column order
+type ndjsonWriter struct {
+	out     io.Writer
+	cols    []Column
+	closers []io.Closer
+}
+
+func (w *ndjsonWriter) Write(row []string) error {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i, c := range w.cols {
+		v, err := Typed(c, row[i])
+		if err != nil {
+			return fmt.Errorf("%v : %v", c.Name, err)
+		}
+		switch t := v.(type) {
+		case time.Time:
+			if c.Kind == "date" {
+				v = t.Format("2006-01-02")
+			} else {
+				v = t.Format(time.RFC3339Nano)
+			}
+		}
+		k, _ := json.Marshal(c.Name)
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.Write(k)
+		sb.WriteString(":")
+		sb.Write(b)
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w.out, sb.String())
+	return err
+}
+
+func (w *ndjsonWriter) Close() error {
+	return closeAll(w.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+var fields = []interface{}{
+	map[string]interface{}{"name": "Id", "type": "id"},
+	map[string]interface{}{"name": "Name", "type": "string"},
+	map[string]interface{}{"name": "NumberOfEmployees", "type": "int"},
+	map[string]interface{}{"name": "AnnualRevenue", "type": "currency"},
+	map[string]interface{}{"name": "IsDeleted", "type": "boolean"},
+	map[string]interface{}{"name": "LastActivityDate", "type": "date"},
+	map[string]interface{}{"name": "CreatedDate", "type": "datetime"},
+}
+
+var rows = [][]string{
+	{"001000000000001AAA", "Acme, Inc", "10", "1500.5", "false", "2021-03-04", "2021-03-04T05:06:07.000+0000", "Bob"},
+	{"001000000000002AAA", "Globex", "", "", "true", "", "", ""},
+}
+
+func columns() []Column {
+	return Columns([]string{"Id", "Name", "NumberOfEmployees", "AnnualRevenue", "IsDeleted", "LastActivityDate", "CreatedDate", "Owner.Name"}, fields)
+}
+
+func write(t *testing.T, path string, format string, gz bool) {
+	w, err := NewWriter(path, format, columns(), gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	want := []string{"string", "string", "int", "double", "boolean", "date", "datetime", "string"}
+	for i, c := range columns() {
+		if c.Kind != want[i] {
+			t.Errorf("%v : expected %v got %v", c.Name, want[i], c.Kind)
+		}
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.ndjson.gz")
+	write(t, path, NDJSON, true)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	want := `{"Id":"001000000000001AAA","Name":"Acme, Inc","NumberOfEmployees":10,"AnnualRevenue":1500.5,"IsDeleted":false,"LastActivityDate":"2021-03-04","CreatedDate":"2021-03-04T05:06:07Z","Owner.Name":"Bob"}`
+	if lines[0] != want {
+		t.Errorf("expected\n%v\ngot\n%v", want, lines[0])
+	}
+	if !strings.Contains(lines[1], `"NumberOfEmployees":null`) {
+		t.Errorf("expected blanks to be null %v", lines[1])
+	}
+}
+
+func TestCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.csv")
+	write(t, path, CSV, false)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("Id,Name,NumberOfEmployees")) || bytes.Count(b, []byte("\n")) != 3 {
+		t.Errorf("unexpected csv\n%v", string(b))
+	}
+}
+
+func TestParquet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.parquet")
+	write(t, path, Parquet, true)
+	fr, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fr.Close()
+	pr, err := reader.NewParquetColumnReader(fr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if n := pr.GetNumRows(); n != 2 {
+		t.Errorf("expected 2 rows got %d", n)
+	}
+	values, _, _, err := pr.ReadColumnByIndex(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != int64(10) || values[1] != nil {
+		t.Errorf("unexpected NumberOfEmployees %v", values)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := map[string]string{"out.csv": CSV, "out.jsonl.gz": NDJSON, "out.parquet": Parquet, "out": CSV}
+	for path, want := range tests {
+		if f, err := Format("", path); err != nil || f != want {
+			t.Errorf("%v : expected %v got %v %v", path, want, f, err)
+		}
+	}
+	if _, err := Format("xml", ""); err == nil {
+		t.Error("expected an error for xml")
+	}
+}
+
+func TestTyped(t *testing.T) {
+	if v, err := Typed(Column{Kind: "int"}, "12.0"); err != nil || v != int64(12) {
+		t.Errorf("got %v %v", v, err)
+	}
+	if _, err := Typed(Column{Kind: "int"}, "12.5"); err == nil {
+		t.Error("expected an error for 12.5")
+	}
+	if _, err := Typed(Column{Kind: "boolean"}, "maybe"); err == nil {
+		t.Error("expected an error for maybe")
+	}
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// writes the rows as a parquet file, every column is optional so blanks are nulls
+type parquetWriter struct {
+	f    *os.File
+	pw   *writer.CSVWriter
+	cols []Column
+}
+
+func newParquetWriter(f *os.File, cols []Column, gz bool) (*parquetWriter, error) {
+	md := make([]string, len(cols))
+	for i, c := range cols {
+		md[i] = fmt.Sprintf("name=%v, %v, repetitiontype=OPTIONAL", c.Name, parquetType(c.Kind))
+	}
+	pw, err := writer.NewCSVWriterFromWriter(md, f, 4)
+	if err != nil {
+		return nil, err
+	}
+	if gz {
+		pw.CompressionType = parquet.CompressionCodec_GZIP
+	}
+	return &parquetWriter{f: f, pw: pw, cols: cols}, nil
+}
+
+func parquetType(kind string) string {
+	switch kind {
+	case "boolean":
+		return "type=BOOLEAN"
+	case "int":
+		return "type=INT64"
+	case "double":
+		return "type=DOUBLE"
+	case "date":
+		return "type=INT32, convertedtype=DATE"
+	case "datetime":
+		return "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	}
+	return "type=BYTE_ARRAY, convertedtype=UTF8"
+}
+
+func (w *parquetWriter) Write(row []string) error {
+	rec := make([]interface{}, len(w.cols))
+	for i, c := range w.cols {
+		v, err := Typed(c, row[i])
+		if err != nil {
+			return fmt.Errorf("%v : %v", c.Name, err)
+		}
+		if t, ok := v.(time.Time); ok {
+			if c.Kind == "date" {
+				v = int32(t.Unix() / 86400)
+			} else {
+				v = t.UnixMilli()
+			}
+		}
+		rec[i] = v
+	}
+	return w.pw.Write(rec)
+}
+
+func (w *parquetWriter) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
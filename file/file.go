@@ -4,11 +4,11 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"os"
 	"strings"
 
 	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/random"
 )
 
 // reads the entire file into a byte []
@@ -59,15 +59,115 @@ func UpdateColumn(filePath string, col string, ids []string) error {
 	if filePath == "" || col == "" || ids == nil {
 		return nil
 	}
-	f, err := os.Open(filePath)
+	data, err := ReadCsv(filePath)
+	if err != nil {
+		return err
+	}
+	values := make([]string, len(data)-1)
+	for i := range values {
+		values[i] = ids[random.Intn(len(ids))]
+	}
+	return writeColumn(filePath, data, col, values)
+}
+
+// sets a column to the values given, one value per data row.
+// writes these changes to the file
+// will append the column if it doesn't exist in the file.
+func SetColumn(filePath string, col string, values []string) error {
+	data, err := ReadCsv(filePath)
 	if err != nil {
 		return err
 	}
+	if len(values) != len(data)-1 {
+		return fmt.Errorf("%d values supplied for %d rows in %v", len(values), len(data)-1, filePath)
+	}
+	return writeColumn(filePath, data, col, values)
+}
+
+// returns the values of a column, one per data row.
+// returns empty values if the column isn't in the file.
+func GetColumn(filePath string, col string) ([]string, error) {
+	data, err := ReadCsv(filePath)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(data)-1)
+	for i, c := range data[0] {
+		if strings.EqualFold(c, col) {
+			for j, row := range data[1:] {
+				values[j] = row[i]
+			}
+		}
+	}
+	return values, nil
+}
+
+// writes the rows of all the files into one CSV at filePath.
+// The header is the union of all the headers, rows are blank for columns their file didn't have.
+func UnionCsv(filePath string, files ...string) (string, error) {
+	var header []string
+	var all [][][]string
+	for _, f := range files {
+		data, err := ReadCsv(f)
+		if err != nil {
+			return "", err
+		}
+		for _, col := range data[0] {
+			if indexOf(header, col) < 0 {
+				header = append(header, col)
+			}
+		}
+		all = append(all, data)
+	}
+	union := [][]string{header}
+	for _, data := range all {
+		for _, row := range data[1:] {
+			r := make([]string, len(header))
+			for i, col := range data[0] {
+				r[indexOf(header, col)] = row[i]
+			}
+			union = append(union, r)
+		}
+	}
+	return WriteCsv(filePath, union)
+}
+
+func indexOf(header []string, col string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, col) {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns the number of data rows (excluding the header) in the file
+func CountRows(filePath string) (int, error) {
+	data, err := ReadCsv(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return len(data) - 1, nil
+}
+
+// reads the whole CSV, errors if there isn't a header row
+func ReadCsv(filePath string) ([][]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
 	data, err := csv.NewReader(f).ReadAll()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%v has no header row", filePath)
 	}
+	return data, nil
+}
+
+func writeColumn(filePath string, data [][]string, col string, values []string) error {
 	var colIndex int = -1
 	var appending bool
 	for i, c := range data[0] { // loop through the header
@@ -81,11 +181,11 @@ func UpdateColumn(filePath string, col string, ids []string) error {
 		data[0] = append(data[0], col)
 	}
 	// loop through all rows
-	for i, _ := range data[1:] {
+	for i := range data[1:] {
 		if appending {
-			data[i+1] = append(data[i+1], ids[rand.Intn(len(ids))])
+			data[i+1] = append(data[i+1], values[i])
 		} else {
-			data[i+1][colIndex] = ids[rand.Intn(len(ids))]
+			data[i+1][colIndex] = values[i]
 		}
 	}
 	if _, err := WriteCsv(filePath, data); err != nil {
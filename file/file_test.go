@@ -24,3 +24,28 @@ func TestOpen(t *testing.T) {
 	}
 	fmt.Printf("We have %d lines\n", len(records))
 }
+
+func TestUnionCsv(t *testing.T) {
+	dir := t.TempDir()
+	a := fmt.Sprintf("%v/a.csv", dir)
+	b := fmt.Sprintf("%v/b.csv", dir)
+	WriteCsv(a, [][]string{{"Name", "Phone"}, {"Acme", "123"}})
+	WriteCsv(b, [][]string{{"LastName", "Phone", "RecordTypeId"}, {"Smith", "456", "012"}})
+
+	out, err := UnionCsv(fmt.Sprintf("%v/union.csv", dir), a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadCsv(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Name", "Phone", "LastName", "RecordTypeId"}, {"Acme", "123", "", ""}, {"", "456", "Smith", "012"}}
+	if fmt.Sprint(data) != fmt.Sprint(want) {
+		t.Errorf("got %v wanted %v", data, want)
+	}
+	phones, _ := GetColumn(out, "phone")
+	if fmt.Sprint(phones) != "[123 456]" {
+		t.Errorf("unexpected phone column %v", phones)
+	}
+}
@@ -0,0 +1,905 @@
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+	Values are nil, bool, int, float64, string, Date, time.Time (a DateTime) and Time.
+	As in ruby only nil and false are false.
+*/
+
+// a date without a time, adding a number adds days
+type Date struct {
+	time.Time
+}
+
+// a ruby Time, unlike a DateTime adding a number adds seconds
+type Time struct {
+	time.Time
+}
+
+// one of the classes a formula can name
+type class string
+
+func (c class) known() bool {
+	return c == "Date" || c == "DateTime" || c == "Time"
+}
+
+// what a formula is evaluated with
+type Env struct {
+	This   interface{}            // the value made for the field
+	Fields map[string]interface{} // the other fields in the row
+	Now    time.Time              // Date.today and DateTime.now, the current time if not set
+}
+
+// evaluates the formula for a row
+func (f *Formula) Eval(env Env) (interface{}, error) {
+	if env.Now.IsZero() {
+		env.Now = random.Now()
+	}
+	v, err := f.root.eval(&env)
+	if err != nil {
+		return nil, fmt.Errorf("%v in %q", err, f.src)
+	}
+	return v, nil
+}
+
+// returns the value as ruby's to_s would, nil is blank
+func String(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		s := strconv.FormatFloat(t, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case Date:
+		return t.Format("2006-01-02")
+	case time.Time:
+		return t.Format("2006-01-02T15:04:05-07:00")
+	case Time:
+		return t.Format("2006-01-02 15:04:05 -0700")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func typeName(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case int:
+		return "Integer"
+	case float64:
+		return "Float"
+	case string:
+		return "String"
+	case Date:
+		return "Date"
+	case time.Time:
+		return "DateTime"
+	case Time:
+		return "Time"
+	case class:
+		return string(t)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+type node interface {
+	eval(env *Env) (interface{}, error)
+}
+
+// calls fn for the node and everything under it
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch t := n.(type) {
+	case *logical:
+		walk(t.left, fn)
+		walk(t.right, fn)
+	case *negate:
+		walk(t.n, fn)
+	case *binary:
+		walk(t.left, fn)
+		walk(t.right, fn)
+	case *call:
+		walk(t.recv, fn)
+		for _, a := range t.args {
+			walk(a, fn)
+		}
+	case *randomCall:
+		walk(t.min, fn)
+		walk(t.max, fn)
+	case *ifNode:
+		for i := range t.conds {
+			walk(t.conds[i], fn)
+			walk(t.thens[i], fn)
+		}
+		if t.els != nil {
+			walk(t.els, fn)
+		}
+	}
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l *literal) eval(env *Env) (interface{}, error) {
+	return l.value, nil
+}
+
+type thisRef struct{}
+
+func (t *thisRef) eval(env *Env) (interface{}, error) {
+	return env.This, nil
+}
+
+type fieldRef struct {
+	name string
+	pos  int
+}
+
+func (f *fieldRef) eval(env *Env) (interface{}, error) {
+	v, ok := env.Fields[f.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %v at %d", f.name, f.pos)
+	}
+	return v, nil
+}
+
+type logical struct {
+	or          bool
+	left, right node
+}
+
+func (l *logical) eval(env *Env) (interface{}, error) {
+	v, err := l.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(v) == l.or {
+		return v, nil
+	}
+	return l.right.eval(env)
+}
+
+type negate struct {
+	n node
+}
+
+func (n *negate) eval(env *Env) (interface{}, error) {
+	v, err := n.n.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+type ifNode struct {
+	conds, thens []node
+	els          node
+}
+
+func (n *ifNode) eval(env *Env) (interface{}, error) {
+	for i, c := range n.conds {
+		v, err := c.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(v) {
+			return n.thens[i].eval(env)
+		}
+	}
+	if n.els == nil {
+		return nil, nil
+	}
+	return n.els.eval(env)
+}
+
+type randomCall struct {
+	min, max node
+	pos      int
+}
+
+// a random number, or date, between min and max inclusive
+func (r *randomCall) eval(env *Env) (interface{}, error) {
+	min, err := r.min.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	max, err := r.max.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch lo := min.(type) {
+	case int:
+		if hi, ok := max.(int); ok {
+			if hi < lo {
+				lo, hi = hi, lo
+			}
+			// a span past the largest int wraps round
+			if span := hi - lo; span < 0 || span == math.MaxInt {
+				return nil, fmt.Errorf("random range from %d to %d is too wide at %d", lo, hi, r.pos)
+			}
+			return lo + random.Intn(hi-lo+1), nil
+		}
+	case Date:
+		if hi, ok := max.(Date); ok {
+			days := int(hi.Sub(lo.Time).Hours() / 24)
+			if days < 0 {
+				return nil, fmt.Errorf("random max is before min at %d", r.pos)
+			}
+			return Date{lo.AddDate(0, 0, random.Intn(days+1))}, nil
+		}
+	case time.Time:
+		if hi, ok := max.(time.Time); ok {
+			if hi.Before(lo) {
+				return nil, fmt.Errorf("random max is before min at %d", r.pos)
+			}
+			if hi.Sub(lo) == math.MaxInt64 {
+				return nil, fmt.Errorf("random range is too wide at %d", r.pos)
+			}
+			return lo.Add(time.Duration(random.Int63n(int64(hi.Sub(lo)) + 1))), nil
+		}
+	case Time:
+		if hi, ok := max.(Time); ok {
+			if hi.Before(lo.Time) {
+				return nil, fmt.Errorf("random max is before min at %d", r.pos)
+			}
+			if hi.Sub(lo.Time) == math.MaxInt64 {
+				return nil, fmt.Errorf("random range is too wide at %d", r.pos)
+			}
+			return Time{lo.Add(time.Duration(random.Int63n(int64(hi.Sub(lo.Time)) + 1)))}, nil
+		}
+	}
+	lo, okLo := number(min)
+	hi, okHi := number(max)
+	if !okLo || !okHi {
+		return nil, fmt.Errorf("random can't choose between %v and %v at %d", typeName(min), typeName(max), r.pos)
+	}
+	return lo + random.Float64()*(hi-lo), nil
+}
+
+func number(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+type binary struct {
+	op          string
+	pos         int
+	left, right node
+}
+
+func (b *binary) eval(env *Env) (interface{}, error) {
+	l, err := b.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch b.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		c, ok := compare(l, r)
+		if !ok {
+			return nil, fmt.Errorf("can't compare %v with %v at %d", typeName(l), typeName(r), b.pos)
+		}
+		switch b.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+	v, ok := arithmetic(b.op, l, r)
+	if !ok {
+		return nil, fmt.Errorf("undefined %v for %v and %v at %d", b.op, typeName(l), typeName(r), b.pos)
+	}
+	if f, isFloat := v.(float64); isFloat && (math.IsInf(f, 0) || math.IsNaN(f)) || v == errDivide {
+		return nil, fmt.Errorf("divided by 0 at %d", b.pos)
+	}
+	return v, nil
+}
+
+// returned by integer division by zero
+var errDivide = struct{}{}
+
+func equal(l, r interface{}) bool {
+	if c, ok := compare(l, r); ok {
+		return c == 0
+	}
+	return l == r
+}
+
+// returns -1, 0 or 1, false if the values can't be ordered
+func compare(l, r interface{}) (int, bool) {
+	if a, ok := number(l); ok {
+		if b, ok := number(r); ok {
+			return order(a < b, a > b), true
+		}
+	}
+	switch a := l.(type) {
+	case string:
+		if b, ok := r.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	}
+	if a, ok := instant(l); ok {
+		if b, ok := instant(r); ok {
+			return a.Compare(b), true
+		}
+	}
+	return 0, false
+}
+
+// the time of a Date, DateTime or Time
+func instant(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case Date:
+		return t.Time, true
+	case time.Time:
+		return t, true
+	case Time:
+		return t.Time, true
+	}
+	return time.Time{}, false
+}
+
+func order(less, more bool) int {
+	if less {
+		return -1
+	}
+	if more {
+		return 1
+	}
+	return 0
+}
+
+const day = 24 * time.Hour
+
+func arithmetic(op string, l, r interface{}) (interface{}, bool) {
+	switch a := l.(type) {
+	case int:
+		if b, ok := r.(int); ok {
+			switch op {
+			case "+":
+				return a + b, true
+			case "-":
+				return a - b, true
+			case "*":
+				return a * b, true
+			case "/", "%":
+				if b == 0 {
+					return errDivide, true
+				}
+				// ruby rounds integer division down, and the remainder takes the sign of the divisor
+				q, m := a/b, a%b
+				if m != 0 && (m < 0) != (b < 0) {
+					q--
+					m += b
+				}
+				if op == "/" {
+					return q, true
+				}
+				return m, true
+			}
+		}
+	case string:
+		switch b := r.(type) {
+		case string:
+			if op == "+" {
+				return a + b, true
+			}
+		case int:
+			if op == "*" && b >= 0 {
+				return strings.Repeat(a, b), true
+			}
+		}
+		return nil, false
+	case Date:
+		if op == "-" {
+			if b, ok := r.(Date); ok {
+				return int(math.Round(a.Sub(b.Time).Hours() / 24)), true
+			}
+		}
+		if n, ok := number(r); ok && (op == "+" || op == "-") {
+			if op == "-" {
+				n = -n
+			}
+			return Date{a.AddDate(0, 0, int(math.Floor(n)))}, true
+		}
+		return nil, false
+	case time.Time:
+		if op == "-" {
+			if b, ok := r.(time.Time); ok {
+				return a.Sub(b).Hours() / 24, true
+			}
+		}
+		if n, ok := number(r); ok && (op == "+" || op == "-") {
+			if op == "-" {
+				n = -n
+			}
+			return a.Add(time.Duration(n * float64(day))), true
+		}
+		return nil, false
+	case Time:
+		if op == "-" {
+			if b, ok := r.(Time); ok {
+				return a.Sub(b.Time).Seconds(), true
+			}
+		}
+		if n, ok := number(r); ok && (op == "+" || op == "-") {
+			if op == "-" {
+				n = -n
+			}
+			return Time{a.Add(time.Duration(n * float64(time.Second)))}, true
+		}
+		return nil, false
+	}
+	a, okA := number(l)
+	b, okB := number(r)
+	if !okA || !okB {
+		return nil, false
+	}
+	switch op {
+	case "+":
+		return a + b, true
+	case "-":
+		return a - b, true
+	case "*":
+		return a * b, true
+	case "/":
+		return a / b, true
+	case "%":
+		return a - b*math.Floor(a/b), true
+	}
+	return nil, false
+}
+
+type call struct {
+	recv node
+	name string
+	pos  int
+	args []node
+}
+
+func (c *call) eval(env *Env) (interface{}, error) {
+	recv, err := c.recv.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, len(c.args))
+	for i, a := range c.args {
+		if args[i], err = a.eval(env); err != nil {
+			return nil, err
+		}
+	}
+	v, err := method(env, recv, c.name, args)
+	if err != nil {
+		return nil, fmt.Errorf("%v at %d", err, c.pos)
+	}
+	return v, nil
+}
+
+// the methods of every value, then those of its type
+func method(env *Env, recv interface{}, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "nil?":
+		return recv == nil, nil
+	case "blank?", "empty?":
+		if s, ok := recv.(string); ok {
+			if name == "empty?" {
+				return s == "", nil
+			}
+			return strings.TrimSpace(s) == "", nil
+		}
+		if name == "blank?" {
+			return recv == nil || recv == false, nil
+		}
+	case "present?":
+		if s, ok := recv.(string); ok {
+			return strings.TrimSpace(s) != "", nil
+		}
+		return truthy(recv), nil
+	case "to_s":
+		return String(recv), nil
+	}
+	switch t := recv.(type) {
+	case string:
+		return stringMethod(t, name, args)
+	case int, float64:
+		n, _ := number(t)
+		return numberMethod(t, n, name, args)
+	case Date:
+		return timeMethod(t.Time, "Date", name, args)
+	case time.Time:
+		return timeMethod(t, "DateTime", name, args)
+	case Time:
+		return timeMethod(t.Time, "Time", name, args)
+	case class:
+		return classMethod(env, t, name, args)
+	}
+	return nil, fmt.Errorf("undefined method %v for %v", name, typeName(recv))
+}
+
+func argCount(name string, args []interface{}, min int, max int) error {
+	if len(args) < min || len(args) > max {
+		return fmt.Errorf("wrong number of arguments for %v", name)
+	}
+	return nil
+}
+
+func intArg(name string, v interface{}) (int, error) {
+	if i, ok := v.(int); ok {
+		return i, nil
+	}
+	return 0, fmt.Errorf("%v takes an Integer, not %v", name, typeName(v))
+}
+
+func stringArg(name string, v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%v takes a String, not %v", name, typeName(v))
+}
+
+func stringMethod(s string, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "[]":
+		return index(s, args)
+	case "length", "size":
+		return len([]rune(s)), argCount(name, args, 0, 0)
+	case "upcase":
+		return strings.ToUpper(s), argCount(name, args, 0, 0)
+	case "downcase":
+		return strings.ToLower(s), argCount(name, args, 0, 0)
+	case "capitalize":
+		if s == "" {
+			return s, nil
+		}
+		r := []rune(strings.ToLower(s))
+		return strings.ToUpper(string(r[0])) + string(r[1:]), argCount(name, args, 0, 0)
+	case "strip":
+		return strings.TrimSpace(s), argCount(name, args, 0, 0)
+	case "reverse":
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r), argCount(name, args, 0, 0)
+	case "to_i":
+		// as ruby does, the leading digits, 0 if there aren't any
+		t := strings.TrimSpace(s)
+		end := 0
+		for end < len(t) && (t[end] >= '0' && t[end] <= '9' || end == 0 && (t[end] == '-' || t[end] == '+')) {
+			end++
+		}
+		i, _ := strconv.Atoi(t[:end])
+		return i, argCount(name, args, 0, 0)
+	case "to_f":
+		f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, argCount(name, args, 0, 0)
+	case "include?", "start_with?", "end_with?":
+		if err := argCount(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		sub, err := stringArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "include?":
+			return strings.Contains(s, sub), nil
+		case "start_with?":
+			return strings.HasPrefix(s, sub), nil
+		}
+		return strings.HasSuffix(s, sub), nil
+	case "gsub", "sub":
+		if err := argCount(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		old, err := stringArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		repl, err := stringArg(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		if name == "sub" {
+			return strings.Replace(s, old, repl, 1), nil
+		}
+		return strings.ReplaceAll(s, old, repl), nil
+	}
+	return nil, fmt.Errorf("undefined method %v for String", name)
+}
+
+// s[i] is the character at i, s[start,length] the substring. Negative positions count from the end,
+// and as in ruby a start past the end is nil
+func index(s string, args []interface{}) (interface{}, error) {
+	r := []rune(s)
+	start, err := intArg("[]", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 {
+		start += len(r)
+	}
+	if len(args) == 1 {
+		if start < 0 || start >= len(r) {
+			return nil, nil
+		}
+		return string(r[start]), nil
+	}
+	length, err := intArg("[]", args[1])
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || start > len(r) || length < 0 {
+		return nil, nil
+	}
+	end := start + length
+	if end > len(r) {
+		end = len(r)
+	}
+	return string(r[start:end]), nil
+}
+
+func numberMethod(v interface{}, n float64, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "to_i", "floor":
+		return int(math.Floor(n)), argCount(name, args, 0, 0)
+	case "ceil":
+		return int(math.Ceil(n)), argCount(name, args, 0, 0)
+	case "to_f":
+		return n, argCount(name, args, 0, 0)
+	case "abs":
+		if i, ok := v.(int); ok {
+			if i < 0 {
+				i = -i
+			}
+			return i, argCount(name, args, 0, 0)
+		}
+		return math.Abs(n), argCount(name, args, 0, 0)
+	case "round":
+		if err := argCount(name, args, 0, 1); err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return int(math.Round(n)), nil
+		}
+		digits, err := intArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		p := math.Pow(10, float64(digits))
+		return math.Round(n*p) / p, nil
+	}
+	return nil, fmt.Errorf("undefined method %v for %v", name, typeName(v))
+}
+
+// the methods of a Date, DateTime or Time, kind is which
+func timeMethod(t time.Time, kind string, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "strftime":
+		if err := argCount(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		format, err := stringArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return strftime(t, format), nil
+	case "iso8601":
+		if kind == "Date" {
+			return t.Format("2006-01-02"), argCount(name, args, 0, 0)
+		}
+		return t.Format("2006-01-02T15:04:05-07:00"), argCount(name, args, 0, 0)
+	case "to_date":
+		y, m, d := t.Date()
+		return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}, argCount(name, args, 0, 0)
+	case "to_datetime":
+		return t, argCount(name, args, 0, 0)
+	case "to_time":
+		return Time{t}, argCount(name, args, 0, 0)
+	case "year":
+		return t.Year(), argCount(name, args, 0, 0)
+	case "month":
+		return int(t.Month()), argCount(name, args, 0, 0)
+	case "day":
+		return t.Day(), argCount(name, args, 0, 0)
+	case "hour":
+		return t.Hour(), argCount(name, args, 0, 0)
+	case "min":
+		return t.Minute(), argCount(name, args, 0, 0)
+	case "wday":
+		return int(t.Weekday()), argCount(name, args, 0, 0)
+	case "yday":
+		return t.YearDay(), argCount(name, args, 0, 0)
+	}
+	return nil, fmt.Errorf("undefined method %v for %v", name, kind)
+}
+
+func classMethod(env *Env, c class, name string, args []interface{}) (interface{}, error) {
+	switch {
+	case c == "Date" && name == "today":
+		y, m, d := env.Now.Date()
+		return Date{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}, argCount(name, args, 0, 0)
+	case c == "Time" && name == "now":
+		return Time{env.Now}, argCount(name, args, 0, 0)
+	case c == "DateTime" && name == "now":
+		return env.Now, argCount(name, args, 0, 0)
+	case name == "parse":
+		if err := argCount(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		s, err := stringArg(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case "Date":
+			return Date{t}, nil
+		case "Time":
+			return Time{t}, nil
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("undefined method %v for %v", name, c)
+}
+
+// the layouts Date.parse understands, and those values arrive in
+var layouts = []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05.000Z", "2006-01-02T15:04:05.000-0700", "2006-01-02 15:04:05", "01/02/2006"}
+
+func parseTime(s string) (time.Time, error) {
+	for _, l := range layouts {
+		if t, err := time.Parse(l, strings.TrimSpace(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// returns the value of a field as a formula sees it. dates, datetimes, numbers and booleans are
+// parsed as mockaroo would pass them (a blank one is nil), anything else is the string it was written as
+func Value(v string, sfType string) interface{} {
+	switch sfType {
+	case "date":
+		if t, err := parseTime(v); err == nil {
+			return Date{t}
+		}
+	case "datetime":
+		if t, err := parseTime(v); err == nil {
+			return t
+		}
+	case "int", "long", "double", "currency", "percent":
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return nil
+		}
+		// whole numbers are integers, as a number without decimals is in mockaroo
+		if i, err := strconv.Atoi(s); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// formats the time as ruby's strftime does, the - flag drops the padding
+func strftime(t time.Time, format string) string {
+	var sb strings.Builder
+	rs := []rune(format)
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '%' || i+1 >= len(rs) {
+			sb.WriteRune(rs[i])
+			continue
+		}
+		i++
+		pad := true
+		if rs[i] == '-' && i+1 < len(rs) {
+			pad = false
+			i++
+		}
+		num := func(n int, width int) string {
+			if pad {
+				return fmt.Sprintf("%0*d", width, n)
+			}
+			return strconv.Itoa(n)
+		}
+		switch rs[i] {
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			sb.WriteString(num(t.Year()%100, 2))
+		case 'm':
+			sb.WriteString(num(int(t.Month()), 2))
+		case 'd':
+			sb.WriteString(num(t.Day(), 2))
+		case 'e':
+			sb.WriteString(fmt.Sprintf("%2d", t.Day()))
+		case 'j':
+			sb.WriteString(num(t.YearDay(), 3))
+		case 'H':
+			sb.WriteString(num(t.Hour(), 2))
+		case 'I':
+			h := t.Hour() % 12
+			if h == 0 {
+				h = 12
+			}
+			sb.WriteString(num(h, 2))
+		case 'M':
+			sb.WriteString(num(t.Minute(), 2))
+		case 'S':
+			sb.WriteString(num(t.Second(), 2))
+		case 'L':
+			sb.WriteString(fmt.Sprintf("%03d", t.Nanosecond()/1e6))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case 'F':
+			sb.WriteString(t.Format("2006-01-02"))
+		case 'T':
+			sb.WriteString(t.Format("15:04:05"))
+		case '%':
+			sb.WriteRune('%')
+		default:
+			sb.WriteRune('%')
+			sb.WriteRune(rs[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,500 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	Parses the part of Mockaroo's formula language our schemas use, so a formula can be checked
+	before it is sent and applied when the data is made without Mockaroo.
+
+	https://www.mockaroo.com/docs#Formulas
+
+	The language looks like ruby
+		if this.nil? then '' else this[0,40] end
+		if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end
+		(DateTime.now - random(1,365)).iso8601
+		field('ActivityDate').strftime('%m/%d/%Y')
+
+	this is the value made for the field, other fields in the row are named by field('Name') or by their name.
+	Date, DateTime and Time are the only classes, dates and datetimes add and subtract days and
+	times seconds.
+*/
+
+// a parsed formula, ready to be evaluated for each row
+type Formula struct {
+	src  string
+	root node
+}
+
+// parses the formula, the error says where the problem is
+func Parse(src string) (*Formula, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%v in %q", err, src)
+	}
+	p := &parser{toks: toks}
+	root, err := p.expr()
+	if err == nil && p.peek().kind != tEOF {
+		err = p.errorf("unexpected %v", p.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v in %q", err, src)
+	}
+	return &Formula{src: src, root: root}, nil
+}
+
+func (f *Formula) String() string {
+	return f.src
+}
+
+// returns the names of the other fields the formula reads
+func (f *Formula) Fields() []string {
+	var names []string
+	seen := make(map[string]bool)
+	walk(f.root, func(n node) {
+		if r, ok := n.(*fieldRef); ok && !seen[r.name] {
+			seen[r.name] = true
+			names = append(names, r.name)
+		}
+	})
+	return names
+}
+
+// lexing
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tNumber
+	tString
+	tIdent
+	tKeyword
+	tOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tEOF:
+		return "end of formula"
+	case tString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+var keywords = map[string]bool{
+	"if": true, "then": true, "elsif": true, "else": true, "end": true,
+	"and": true, "or": true, "not": true, "nil": true, "true": true, "false": true, "this": true,
+}
+
+// two character operators are checked before one character ones
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	rs := []rune(src)
+	i := 0
+	for i < len(rs) {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			// a dot is only part of the number if a digit follows, 1.to_s is a method call
+			if i+1 < len(rs) && rs[i] == '.' && unicode.IsDigit(rs[i+1]) {
+				i++
+				for i < len(rs) && unicode.IsDigit(rs[i]) {
+					i++
+				}
+			}
+			toks = append(toks, token{tNumber, strings.ReplaceAll(string(rs[start:i]), "_", ""), start})
+		case c == '\'' || c == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(rs) && rs[i] != c; i++ {
+				if rs[i] == '\\' && i+1 < len(rs) {
+					i++
+					if c == '"' {
+						switch rs[i] {
+						case 'n':
+							sb.WriteRune('\n')
+							continue
+						case 't':
+							sb.WriteRune('\t')
+							continue
+						}
+					} else if rs[i] != '\'' && rs[i] != '\\' {
+						// single quotes only escape the quote and the backslash
+						sb.WriteRune('\\')
+					}
+				}
+				sb.WriteRune(rs[i])
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			toks = append(toks, token{tString, sb.String(), start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			// ruby methods can end with ? or !, but not != as in this!=nil
+			if i < len(rs) && (rs[i] == '?' || (rs[i] == '!' && (i+1 >= len(rs) || rs[i+1] != '='))) {
+				i++
+			}
+			word := string(rs[start:i])
+			kind := tIdent
+			if keywords[word] {
+				kind = tKeyword
+			}
+			toks = append(toks, token{kind, word, start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(rs[i:]), op) {
+					toks = append(toks, token{tOp, op, i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+		}
+	}
+	return append(toks, token{tEOF, "", len(rs)}), nil
+}
+
+// parsing, each level of precedence is a method
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+// consumes the token if it is the keyword or operator
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tKeyword || t.kind == tOp) && t.text == text {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q but found %v", text, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%v at %d", fmt.Sprintf(format, args...), p.peek().pos)
+}
+
+func (p *parser) expr() (node, error) {
+	return p.or()
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") || p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") || p.accept("&&") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (node, error) {
+	if p.accept("not") || p.accept("!") {
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &negate{n}, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		t := p.peek()
+		if p.accept(op) {
+			right, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return &binary{op: op, pos: t.pos, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) additive() (node, error) {
+	left, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("+") && !p.accept("-") {
+			return left, nil
+		}
+		right, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: t.text, pos: t.pos, left: left, right: right}
+	}
+}
+
+func (p *parser) multiplicative() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !p.accept("*") && !p.accept("/") && !p.accept("%") {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: t.text, pos: t.pos, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	t := p.peek()
+	if p.accept("-") {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &binary{op: "-", pos: t.pos, left: &literal{0}, right: n}, nil
+	}
+	return p.postfix()
+}
+
+// method calls and indexes, this.nil? or this[0,40]
+func (p *parser) postfix() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tIdent && name.kind != tKeyword {
+				return nil, fmt.Errorf("expected a method name but found %v at %d", name, name.pos)
+			}
+			var args []node
+			if p.accept("(") {
+				if args, err = p.args(")"); err != nil {
+					return nil, err
+				}
+			}
+			n = &call{recv: n, name: name.text, pos: name.pos, args: args}
+		case p.accept("["):
+			args, err := p.args("]")
+			if err != nil {
+				return nil, err
+			}
+			if len(args) == 0 || len(args) > 2 {
+				return nil, fmt.Errorf("an index takes one or two arguments at %d", t.pos)
+			}
+			n = &call{recv: n, name: "[]", pos: t.pos, args: args}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// the arguments up to the closing bracket, which is consumed
+func (p *parser) args(close string) ([]node, error) {
+	var args []node
+	if p.accept(close) {
+		return args, nil
+	}
+	for {
+		a, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if p.accept(close) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tNumber:
+		if strings.Contains(t.text, ".") {
+			f, err := strconv.ParseFloat(t.text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad number %v at %d", t.text, t.pos)
+			}
+			return &literal{f}, nil
+		}
+		i, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %v at %d", t.text, t.pos)
+		}
+		return &literal{i}, nil
+	case tString:
+		return &literal{t.text}, nil
+	case tKeyword:
+		switch t.text {
+		case "nil":
+			return &literal{nil}, nil
+		case "true":
+			return &literal{true}, nil
+		case "false":
+			return &literal{false}, nil
+		case "this":
+			return &thisRef{}, nil
+		case "if":
+			return p.ifExpr()
+		}
+	case tIdent:
+		if class(t.text).known() {
+			return &literal{class(t.text)}, nil
+		}
+		if p.accept("(") {
+			args, err := p.args(")")
+			if err != nil {
+				return nil, err
+			}
+			return p.function(t, args)
+		}
+		// any other name is a field in the row
+		return &fieldRef{name: t.text, pos: t.pos}, nil
+	case tOp:
+		if t.text == "(" {
+			n, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %v at %d", t, t.pos)
+}
+
+// the functions that aren't methods
+func (p *parser) function(t token, args []node) (node, error) {
+	switch t.text {
+	case "field":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("field takes the name of a field at %d", t.pos)
+		}
+		if name, ok := args[0].(*literal); ok {
+			if s, ok := name.value.(string); ok {
+				return &fieldRef{name: s, pos: t.pos}, nil
+			}
+		}
+		return nil, fmt.Errorf("field takes the name of a field as a string at %d", t.pos)
+	case "random":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("random takes a min and a max at %d", t.pos)
+		}
+		return &randomCall{min: args[0], max: args[1], pos: t.pos}, nil
+	}
+	return nil, fmt.Errorf("unknown function %v at %d", t.text, t.pos)
+}
+
+// if cond then a elsif cond then b else c end, then can be left out
+func (p *parser) ifExpr() (node, error) {
+	n := &ifNode{}
+	for {
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		p.accept("then")
+		then, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		n.conds = append(n.conds, cond)
+		n.thens = append(n.thens, then)
+		if !p.accept("elsif") {
+			break
+		}
+	}
+	if p.accept("else") {
+		els, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		n.els = els
+	}
+	return n, p.expect("end")
+}
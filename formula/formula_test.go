@@ -0,0 +1,201 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	fields := map[string]interface{}{
+		"ActivityDate": Date{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		"Subject":      "Call back",
+		"Amount":       1250,
+	}
+	tests := []struct {
+		formula string
+		this    interface{}
+		want    string
+	}{
+		{"if this.nil? then '' else this[0,5] end", "Lorem ipsum", "Lorem"},
+		{"if this.nil? then '' else this[0,5] end", nil, ""},
+		{"this[0,40]", "short", "short"},
+		{"this[-3,3]", "abcdef", "def"},
+		{"this[10,2]", "abc", ""},
+		{"this[1]", "abc", "b"},
+		{"Date.today - 1", nil, "2024-03-14"},
+		{"Date.today + 17", nil, "2024-04-01"},
+		{"(DateTime.now - 1).iso8601", nil, "2024-03-14T10:30:00+00:00"},
+		{"(DateTime.now + 1.5).iso8601", nil, "2024-03-16T22:30:00+00:00"},
+		{"Time.now + 1.5", nil, "2024-03-15 10:30:01 +0000"},
+		{"(Time.now - 3600).iso8601", nil, "2024-03-15T09:30:00+00:00"},
+		{"Time.now - Time.parse('2024-03-15 10:00:00')", nil, "1800.0"},
+		{"Time.now > DateTime.now - 1", nil, "true"},
+		{"field('ActivityDate').strftime('%m/%d/%Y')", nil, "02/29/2024"},
+		{"ActivityDate.strftime('%-d %b %Y')", nil, "29 Feb 2024"},
+		{"Date.today - ActivityDate", nil, "15"},
+		{"Subject + ': ' + this.upcase", "urgent", "Call back: URGENT"},
+		{"if Amount > 1000 and Subject.include?('Call') then 'big' elsif Amount > 100 then 'medium' else 'small' end", nil, "big"},
+		{"if not this.blank? && this != 'x' then 1 else 2 end", "y", "1"},
+		{"7 / 2", nil, "3"},
+		{"-7 / 2", nil, "-4"},
+		{"7.0 / 2", nil, "3.5"},
+		{"-7 % 3", nil, "2"},
+		{"'ab' * 3", nil, "ababab"},
+		{"(Amount * 1.1).round(1)", nil, "1375.0"},
+		{"this.to_i + 1", "41 apples", "42"},
+		{"if this then 'yes' end", false, ""},
+		{"Date.parse('2024-01-31') + 1", nil, "2024-02-01"},
+		{`"line\n" + 'it\'s'`, nil, "line\nit's"},
+	}
+	for _, test := range tests {
+		f, err := Parse(test.formula)
+		if err != nil {
+			t.Errorf("%v : %v", test.formula, err)
+			continue
+		}
+		v, err := f.Eval(Env{This: test.this, Fields: fields, Now: now})
+		if err != nil {
+			t.Errorf("%v : %v", test.formula, err)
+			continue
+		}
+		if got := String(v); got != test.want {
+			t.Errorf("%v : expected %q got %q", test.formula, test.want, got)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	now := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	f, err := Parse("if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var past int
+	for i := 0; i < 500; i++ {
+		v, err := f.Eval(Env{Now: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, ok := v.(Date)
+		if !ok {
+			t.Fatalf("expected a Date got %v", typeName(v))
+		}
+		if days := d.Sub(now.Truncate(day)).Hours() / 24; days < -365 || days > 365 {
+			t.Errorf("%v is more than a year from today", String(d))
+		}
+		if d.Before(now.Truncate(day)) {
+			past++
+		}
+	}
+	// 8 in 11 are in the past
+	if past < 300 || past > 450 {
+		t.Errorf("expected about 70%% in the past, got %d of 500", past)
+	}
+	f, err = Parse("random(1.5, 2.5)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := f.Eval(Env{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v.(float64); !ok || n < 1.5 || n > 2.5 {
+		t.Errorf("expected a float between 1.5 and 2.5, got %v", v)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	parse := []struct {
+		formula string
+		want    string
+	}{
+		// the formula getSchemaForGenericObj used to send, missing its if
+		{"this.nil? then '' else this[0,40] end", "unexpected \"then\" at 10"},
+		{"if this.nil? then '' else this[0,40]", "expected \"end\""},
+		{"this[0,40", "expected \",\""},
+		{"'unterminated", "unterminated string at 0"},
+		{"lookup('Name')", "unknown function lookup"},
+		{"random(1)", "random takes a min and a max"},
+		{"field(Name)", "field takes the name of a field as a string"},
+		{"this # that", "unexpected '#' at 5"},
+		{"this[]", "an index takes one or two arguments"},
+	}
+	for _, test := range parse {
+		_, err := Parse(test.formula)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%v : expected an error with %q got %v", test.formula, test.want, err)
+		}
+	}
+
+	eval := []struct {
+		formula string
+		want    string
+	}{
+		{"this[0,5]", "undefined method [] for nil at 4"},
+		{"this.frobnicate", "undefined method frobnicate for nil"},
+		{"Date.today + 'x'", "undefined + for Date and String"},
+		{"field('Missing')", "unknown field Missing"},
+		{"1 / 0", "divided by 0"},
+		{"Date.today.strftime(1)", "strftime takes a String"},
+		{"this < 1", "can't compare nil with Integer"},
+		{"random(0, 9223372036854775807)", "random range from 0 to 9223372036854775807 is too wide"},
+		{"random(-9223372036854775807, 1)", "is too wide"},
+		{"random(DateTime.parse('1700-01-01'), DateTime.parse('2024-01-01'))", "random range is too wide"},
+	}
+	for _, test := range eval {
+		f, err := Parse(test.formula)
+		if err != nil {
+			t.Errorf("%v : %v", test.formula, err)
+			continue
+		}
+		_, err = f.Eval(Env{})
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%v : expected an error with %q got %v", test.formula, test.want, err)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	f, err := Parse("if field('Type') == 'Call' then Subject + ActivityDate.to_s + field('Type') else this end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(f.Fields(), ","); got != "Type,Subject,ActivityDate" {
+		t.Errorf("expected Type,Subject,ActivityDate got %v", got)
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		formula string
+		value   string
+		sfType  string
+		want    string
+	}{
+		{"this + 1", "41", "int", "42"},
+		{"this * 2", "2.5", "currency", "5.0"},
+		{"this * 2", "3", "double", "6"},
+		{"(this / 4).round(2)", "12.5", "percent", "3.13"},
+		{"this > 1000", "1250.00", "currency", "true"},
+		{"if this then 'yes' else 'no' end", "false", "boolean", "no"},
+		{"if this then 'yes' else 'no' end", "true", "boolean", "yes"},
+		{"if this.nil? then 'none' else this end", "", "int", "none"},
+		{"this + 'x'", "12", "string", "12x"},
+	}
+	for _, test := range tests {
+		f, err := Parse(test.formula)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := f.Eval(Env{This: Value(test.value, test.sfType)})
+		if err != nil {
+			t.Errorf("%v with %q : %v", test.formula, test.value, err)
+			continue
+		}
+		if got := String(v); got != test.want {
+			t.Errorf("%v with %q : expected %q got %q", test.formula, test.value, test.want, got)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -13,196 +12,172 @@ import (
 	"github.com/simpleforce/simpleforce"
 	"github.com/troysellers/go-modifier/config"
 	"github.com/troysellers/go-modifier/file"
-	"github.com/troysellers/go-modifier/mockaroo"
+	"github.com/troysellers/go-modifier/lookup"
+	"github.com/troysellers/go-modifier/mask"
 	"github.com/troysellers/go-modifier/sforce"
+	"github.com/troysellers/go-modifier/staging"
 )
 
 func init() {
 	if err := godotenv.Load(); err != nil {
 		log.Print("No .env file found")
 	}
+	register(command{name: "update", summary: "modify the records returned by SOQL queries", run: updateCommand})
 }
 
 func main() {
 	start := time.Now()
-	var op = flag.String("op", "", "create | update")
-	var query = flag.Bool("query", true, "(update) run the query only, do not execute the update in Salesforce")
-	var count = flag.Int("count", 10, "(create) how many records to get from mockaroo")
-	var obj = flag.String("obj", "", "(create) specify which salesforce object do you want to create")
-	var references = flag.Bool("references", true, "(create) set to true if you want to populate reference fields to random data in the Salesforce org. ")
-	var fetchOnly = flag.Bool("fetch", false, "(create) When true will fetch and merge mockaroo data but will not send to Salesforce.")
-	var whoObj = flag.String("who", "", "(create) If creating activities (tasks/events) you need to specify the who object (user|contact)")
-	var whatObj = flag.String("what", "", "(create) If creating activities (tasks/events) you need to specify the what object (any activity enabled obj)")
-	var personAccounts = flag.Bool("personaccounts", false, "(create) Set to true if you want to create person accounts (or relate other objects to person accounts).")
-
-	flag.Parse()
+	code := run(os.Args[1:], os.Stderr)
+	if code == exitOK || code == exitPartial {
+		log.Printf("Completed in %v", time.Since(start))
+	}
+	os.Exit(code)
+}
 
-	if *op == "" {
-		flag.PrintDefaults()
-		os.Exit(1)
+// runs each query, changes the updateable fields and (unless it is query only) writes the records back
+func updateCommand(args []string) error {
+	fs := newFlagSet("update", "[-query=false] [-soql \"select ...\"] [-mask] [-seed n] [-date YYYY-MM-DD] [-locale en_US]")
+	var query = fs.Bool("query", true, "run the query only, do not execute the update in Salesforce")
+	var soql = fs.String("soql", "", "a query to modify, defaults to the QUERIES setting")
+	var masked = fs.Bool("mask", false, "mask the values with MASK_KEY and MASK_POLICIES instead of replacing them with random ones")
+	var seed, date = seedFlag(fs)
+	var locale = localeFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
 	}
-	cfg := config.NewConfig()
-	c, err := sforce.NewRestClient(&cfg.SF)
+	cfg, err := loadConfig()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	// get a syncMap to store any downloaded Ids so we only do this once.
-	var objIds sync.Map
-
-	switch *op {
-	case "writefile":
-		if err := sforce.UploadCSVToSalesforce(cfg, c, "/tmp/mockaroo-data/account-update.csv", "Account"); err != nil {
-			panic(err)
-		}
-	case "closecases":
-		qj, err := sforce.GetBulkQuery(cfg, c, "select id, status from case where isClosed=false and createddate < LAST_WEEK")
-		if err != nil {
-			panic(err)
-		}
-		for _, row := range qj.QueryData[1:] {
-			row[1] = "Closed"
-		}
-		filePath := "/tmp/mockaroo-data/closeCase.csv"
-		file.WriteCsv(filePath, qj.QueryData)
-		if err := sforce.UploadCSVToSalesforce(cfg, c, filePath, qj.BulkJob.Object); err != nil {
-			panic(err)
-		}
-	case "update":
-		var wg sync.WaitGroup
-		for _, q := range cfg.SF.Queries {
-			wg.Add(1)
-			go modify(q, cfg, &wg, *query, c, &objIds)
-		}
-		wg.Wait()
-	case "create":
-		log.Printf("Creating for %v\n", *obj)
-		if *personAccounts && strings.EqualFold(*obj, "contact") {
-			panic("if you wish to create Contacts that are Person Accounts you need to specify account as the object")
-		}
-		o := c.SObject(*obj)
-		mr := &mockaroo.MockarooRequest{
-			SObject:        o.Describe(),
-			Cfg:            cfg,
-			Count:          *count,
-			PersonAccounts: *personAccounts,
-		}
-
-		if err := mr.GetDataForObj(); err != nil {
-			panic(err)
+	if err := useLocales(*locale, cfg); err != nil {
+		return err
+	}
+	if err := useTextModel(cfg); err != nil {
+		return err
+	}
+	if *masked {
+		if err := cfg.Mask.Check(); err != nil {
+			return configError("%v", err)
 		}
-
-		if *references {
-			fields := mr.Schema
-			for _, f := range fields {
-				field := f.GetField().SforceMeta
-				// this will replace the arrays from the metadata for referenceTo fields
-				// with the user entered values for Who and What.
-				//if strings.EqualFold(*obj, "task") || strings.EqualFold(*obj, "event") {
-
-				if field["relationshipName"] == "Who" {
-					field["referenceTo"] = []interface{}{*whoObj}
-				}
-				if field["relationshipName"] == "What" {
-					field["referenceTo"] = []interface{}{*whatObj}
-				}
-				//	}
-				// look for the relationship fields that have been included in the schema
-				if field["relationshipName"] != nil {
-					log.Println(f.GetField().Name)
-					// fetch all the possible Ids for this.
-					fieldName := field["name"].(string)
-					rt := field["referenceTo"].([]interface{})
-					var referenceTo string
-					if fieldName == "OwnerId" {
-						referenceTo = "User"
-					} else {
-						referenceTo = rt[0].(string)
-					}
-					if referenceTo != "" {
-						_, ok := objIds.Load(referenceTo)
-						if !ok {
-							// if not, get and cache in the sync.Map
-							ids, err := sforce.GetAllObjIds(cfg, referenceTo, c)
-							if err != nil {
-								panic(err)
-							}
-							objIds.Store(referenceTo, ids)
-						}
-						if err := updateIds(cfg, mr.FilePath, referenceTo, fieldName, &objIds, c); err != nil {
-							panic(err)
-						}
-					}
-				}
+		for k, p := range cfg.Mask.Policies {
+			if _, err := mask.ParsePolicy(p); err != nil {
+				return configError("MASK_POLICIES %v : %v", k, err)
 			}
-		} else if err := updateIds(cfg, mr.FilePath, "user", "ownerId", &objIds, c); err != nil { // always update the owner
-			panic(err)
 		}
-		if !*fetchOnly {
-			// write data into Salesforce
-			if err := sforce.UploadCSVToSalesforce(cfg, c, mr.FilePath, *obj); err != nil {
-				panic(err)
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return err
+	}
+	if err := useSeed(*seed, *date); err != nil {
+		return err
+	}
+	queries := cfg.SF.Queries
+	if *soql != "" {
+		queries = []string{*soql}
+	}
+	if len(queries) == 0 || strings.TrimSpace(queries[0]) == "" {
+		return configError("no queries to run, set QUERIES or use -soql")
+	}
+	// get a syncMap to store any downloaded Ids so we only do this once.
+	var objIds sync.Map
+	var wg sync.WaitGroup
+	errs := make([]error, len(queries))
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			if err := modify(q, cfg, *query, *masked, c, &objIds); err != nil {
+				errs[i] = fmt.Errorf("[%v] %w", q, err)
 			}
+		}(i, q)
+		// a seeded run makes its random choices in the same order each time, so the queries take turns
+		if *seed != 0 {
+			wg.Wait()
 		}
 	}
-
-	elapsed := time.Since(start)
-	log.Printf("Completed %v in %v", *op, elapsed)
+	wg.Wait()
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return summarise(failed, len(queries))
 }
 
 //
 // f - filename that contains the CSV to modify
+// sobj - the object the CSV holds records for, used to find any configured distribution for col
 // obj - the object name of the referenced field (e.g. if you want to update the ownerId col, this should be user)
 // col - the column in the CSV that references this object (e.g. it would be "ownerId" if you wanted to update record owners)
-// objIds - the syncMap that contains all the previously downloaded sets of Ids - trying to save some time.
+// objIds - the syncMap that contains all the previously downloaded sets of Ids (one per candidate filter) - trying to save some time.
 // c - the salesforce REST Client in case we need to get some more id values
 //
 // function updates a column with random values selected from the complete set of possibles out of salesforce.
+// If LOOKUP_DISTRIBUTIONS has an entry for sobj.col the values are spread across the parents using that distribution.
 // this can take some time, we execute bulk queries in case you want to randomly select from 1 million accounts (as an example)
-func updateIds(cfg *config.Config, f string, obj string, col string, objIds *sync.Map, c *simpleforce.Client) error {
-	var ids []string
-	var err error
-	i, ok := objIds.Load(obj) // test if we have the list of IDs in our cache
-	if !ok {
-		ids, err = sforce.GetAllObjIds(cfg, obj, c) // download them if we don't
-		if err != nil {
+func updateIds(cfg *config.Config, f string, sobj string, obj string, col string, objIds *sync.Map, c *simpleforce.Client) error {
+	// fetch the candidates, filtered by LOOKUP_FILTERS if there is one for this field
+	ids, err := sforce.GetCandidateIds(cfg, c, objIds, sobj, col, obj)
+	if err != nil {
+		return err
+	}
+	spec := cfg.Lookups.DistributionFor(sobj, col)
+	if spec == "" {
+		if err := file.UpdateColumn(f, col, ids); err != nil {
 			return err
 		}
-		objIds.Store(obj, ids) // store in our cache
-	} else {
-		ids = i.([]string)
+		return nil
+	}
+	dist, err := lookup.ParseDistribution(spec)
+	if err != nil {
+		return err
 	}
-	if err := file.UpdateColumn(f, col, ids); err != nil {
+	rows, err := file.CountRows(f)
+	if err != nil {
 		return err
 	}
+	values := dist.Assign(rows, ids)
+	if n := lookup.Unassigned(values); n > 0 {
+		log.Printf("%v.%v : %d of %d rows couldn't be given a %v with %v and are left blank", sobj, col, n, rows, obj, spec)
+	}
+	if err := file.SetColumn(f, col, values); err != nil {
+		return err
+	}
+	log.Printf("%v.%v assigned with %v across %d %v records\n%v", sobj, col, spec, len(ids), obj, lookup.Histogram(ids, values))
 	return nil
 }
 
-func modify(q string, cfg *config.Config, wg *sync.WaitGroup, queryOnly bool, c *simpleforce.Client, objIds *sync.Map) {
+// queries the records, changes (or masks) the updateable fields and, unless queryOnly, writes them back
+func modify(q string, cfg *config.Config, queryOnly bool, masked bool, c *simpleforce.Client, objIds *sync.Map) error {
 
-	defer wg.Done()
 	log.Printf("Query to run %v : query only %v", q, queryOnly)
 	queryJob, err := sforce.GetBulkQuery(cfg, c, q)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	// change the fields in the data
 	// depending on the query, this can take some time if it is populating referenced fields randomly.
-	if err := queryJob.ModifyData(cfg, objIds, c); err != nil {
-		panic(err)
+	if masked {
+		if err := queryJob.MaskData(cfg); err != nil {
+			return err
+		}
+	} else if err := queryJob.ModifyData(cfg, objIds, c); err != nil {
+		return err
 	}
 	// write the CSV back to file
 	fPath, err := file.BuildFilePath(fmt.Sprintf("%v-query-modified.csv", queryJob.BulkJob.Object), cfg)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	d2, err := file.WriteCsv(fPath, queryJob.QueryData)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	staging.Record(cfg, staging.Modified, queryJob.BulkJob.Object, queryJob.BulkJob.Id, queryJob.QueryData)
 	if !queryOnly {
-
-		if err := sforce.UploadCSVToSalesforce(cfg, c, d2, queryJob.BulkJob.Object); err != nil {
-			panic(err)
-		}
+		return sforce.UploadCSVToSalesforce(cfg, c, d2, queryJob.BulkJob.Object)
 	}
+	return nil
 }
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"math"
 	"math/rand"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/sforce"
 )
 
 func TestMod(t *testing.T) {
@@ -72,3 +83,143 @@ func getData(n string) [][]string {
 	return leadData
 
 }
+
+func TestParseActivityTargets(t *testing.T) {
+	who, what, err := parseActivityTargets("Contact:80,Lead:20", "Account:50,Opportunity:30,Case:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(who) != 2 || len(what) != 3 {
+		t.Errorf("unexpected targets %v %v", who, what)
+	}
+	if _, _, err := parseActivityTargets("Account", ""); err == nil {
+		t.Error("expected an error for a who that isn't a Contact or Lead")
+	}
+	if _, _, err := parseActivityTargets("", "Lead:10"); err == nil {
+		t.Error("expected an error for a what that is a Lead")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	failed := &sforce.FailedRecordsError{Object: "Account", Operation: "upsert", Processed: 10, Failed: 2}
+	allFailed := &sforce.FailedRecordsError{Object: "Account", Operation: "upsert", Processed: 10, Failed: 10}
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitOK},
+		{errors.New("boom"), exitFailure},
+		{configError("-obj is required"), exitConfig},
+		{fmt.Errorf("wrapped : %w", failed), exitPartial},
+		{allFailed, exitFailure},
+		{summarise([]error{errors.New("one")}, 2), exitPartial},
+		{summarise([]error{errors.New("one"), errors.New("two")}, 2), exitFailure},
+		{summarise([]error{errors.New("one"), failed}, 2), exitPartial},
+	}
+	for _, tc := range tests {
+		if code := exitCode(tc.err); code != tc.code {
+			t.Errorf("%v : expected exit code %d got %d", tc.err, tc.code, code)
+		}
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, exitConfig},
+		{[]string{"help"}, exitOK},
+		{[]string{"help", "create"}, exitOK},
+		{[]string{"nope"}, exitConfig},
+		{[]string{"-op", "create"}, exitConfig},
+		{[]string{"create"}, exitConfig},
+		{[]string{"create", "-obj", "account", "-count", "0"}, exitConfig},
+		{[]string{"create", "-obj", "account", "-personratio", "2"}, exitConfig},
+		{[]string{"create", "-obj", "task", "-who", "Account:100"}, exitConfig},
+		{[]string{"create", "-nope"}, exitConfig},
+		{[]string{"describe", "extra"}, exitConfig},
+		{[]string{"delete"}, exitConfig},
+		{[]string{"load", "-obj", "Account"}, exitConfig},
+		{[]string{"export", "-format", "xml"}, exitConfig},
+		{[]string{"load", "-stage", "generated"}, exitConfig},
+		{[]string{"load", "-stage", "generated:Account", "-file", "go.mod"}, exitConfig},
+		{[]string{"load", "-file", "nope.csv", "-obj", "Account"}, exitConfig},
+		{[]string{"load", "-file", "go.mod", "-obj", "Account", "-op", "merge"}, exitConfig},
+		{[]string{"copy"}, exitConfig},
+		{[]string{"copy", "-objects", "Account", "-missing", "keep"}, exitConfig},
+		{[]string{"copy", "-objects", "Account", "-where", "Contact=Name != null"}, exitConfig},
+		{[]string{"update", "-locale", "xx_XX"}, exitConfig},
+		{[]string{"train-text", "-csv", "cases.csv"}, exitConfig},
+		{[]string{"create", "-obj", "account", "-merge"}, exitConfig},
+		{[]string{"create", "-obj", "personaccount", "-schema", "Curated Accounts"}, exitConfig},
+		{[]string{"upload-dataset"}, exitConfig},
+		{[]string{"lint-schema"}, exitConfig},
+		{[]string{"lint-schema", "-obj", "Account", "-file", "schema.json"}, exitConfig},
+		{[]string{"lint-schema", "-file", "nope.json"}, exitFailure},
+	}
+	for _, tc := range tests {
+		var out bytes.Buffer
+		if code := run(tc.args, &out); code != tc.code {
+			t.Errorf("%v : expected exit code %d got %d\n%v", tc.args, tc.code, code, out.String())
+		}
+	}
+}
+
+func TestCreateParentsCycle(t *testing.T) {
+	if err := createParents(nil, nil, nil, "Account", createOptions{obj: "Account", count: 10}); err == nil {
+		t.Error("expected an error for an object that needs records of its own")
+	}
+	err := createParents(nil, nil, nil, "a__c", createOptions{obj: "B__c", count: 10, creating: []string{"Contact", "A__c"}})
+	if err == nil || !strings.Contains(err.Error(), "Contact -> A__c -> B__c -> a__c") {
+		t.Errorf("expected the cycle to be named, got %v", err)
+	}
+}
+
+func TestCreateParentsFetchOnly(t *testing.T) {
+	// without a client anything that reached Salesforce would panic
+	err := createParents(nil, nil, nil, "Account", createOptions{obj: "Contact", count: 10, fetchOnly: true})
+	if err == nil || !strings.Contains(err.Error(), "-fetch") {
+		t.Errorf("expected a fetch only run to refuse to create parents, got %v", err)
+	}
+}
+
+func TestMapWithIdNoId(t *testing.T) {
+	if _, err := mapWithId([][]string{{"Name"}, {"Acme"}}, "mapping.json"); err == nil || !strings.Contains(err.Error(), "no Id column") {
+		t.Errorf("expected an error for data without an Id column, got %v", err)
+	}
+}
+
+func TestAssignPolymorphicSeeded(t *testing.T) {
+	var objIds sync.Map
+	for _, obj := range []string{"Account", "Opportunity", "Case"} {
+		objIds.Store(obj, []string{obj + "1", obj + "2", obj + "3", obj + "4"})
+	}
+	targets := []string{"Case", "Account", "", "Opportunity", "Account", "Case", "Opportunity", "Account"}
+	assign := func() string {
+		random.SetSeed(42)
+		ids, err := assignPolymorphic(&config.Config{}, "Task", "WhatId", targets, &objIds, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(ids, ",")
+	}
+	first := assign()
+	for i := 0; i < 20; i++ {
+		if got := assign(); got != first {
+			t.Fatalf("expected the same Ids from the same seed\n%v\n%v", first, got)
+		}
+	}
+}
+
+func TestReferencedObject(t *testing.T) {
+	sobj := &simpleforce.SObjectMeta{"name": "Contact", "fields": []interface{}{
+		map[string]interface{}{"name": "AccountId", "referenceTo": []interface{}{"Account"}},
+	}}
+	for field, want := range map[string]string{"accountid": "Account", "AccountId": "Account", "ownerid": "User"} {
+		if got, err := referencedObject(sobj, field); err != nil || got != want {
+			t.Errorf("%v : expected %v got %v %v", field, want, got, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/troysellers/go-modifier/mockaroo"
+	"github.com/troysellers/go-modifier/rules"
+)
+
+func init() {
+	register(command{name: "lint-schema", summary: "check the formulas of the Mockaroo schemas without calling Mockaroo", run: lintSchemaCommand})
+}
+
+// builds the schema create would send for each object, or reads a saved one, and checks its formulas
+func lintSchemaCommand(args []string) error {
+	fs := newFlagSet("lint-schema", "-obj <object>[,<object>...] | -file <schema.json> [-personaccounts]")
+	var objs = fs.String("obj", "", "comma separated objects to build and check the schemas of, as create would")
+	var path = fs.String("file", "", "a schema saved as Mockaroo json to check, Salesforce isn't needed")
+	var personAccounts = fs.Bool("personaccounts", false, "also check the person account schema for Account")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if (*objs == "") == (*path == "") {
+		return configError("one of -obj or -file is required")
+	}
+	if *path != "" {
+		if err := mockaroo.LintFile(*path); err != nil {
+			return fmt.Errorf("%v has formula errors\n%v", *path, err)
+		}
+		fmt.Printf("%v ok\n", *path)
+		return nil
+	}
+	cfg, c, err := connect()
+	if err != nil {
+		return err
+	}
+	rs, err := rules.Get(cfg)
+	if err != nil {
+		return configError("%v", err)
+	}
+	var errs []error
+	names := strings.Split(*objs, ",")
+	for _, obj := range names {
+		obj = strings.TrimSpace(obj)
+		meta := c.SObject(obj).Describe()
+		if meta == nil {
+			errs = append(errs, fmt.Errorf("unable to describe %v", obj))
+			continue
+		}
+		if err := mockaroo.LintObject(meta, *personAccounts, rs); err != nil {
+			errs = append(errs, fmt.Errorf("%v has formula errors\n%v", obj, err))
+			continue
+		}
+		fmt.Printf("%v ok\n", obj)
+	}
+	return summarise(errs, len(names))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/mapping"
+	"github.com/troysellers/go-modifier/sforce"
+	"github.com/troysellers/go-modifier/staging"
+)
+
+func init() {
+	register(command{name: "load", summary: "load any CSV into an object, optionally through a mapping file or a Data Loader process", run: loadCommand})
+}
+
+var loadOperations = []string{"insert", "update", "upsert", "delete", "hardDelete"}
+
+// maps the CSV to the object's fields and sends it to Salesforce with the operation
+func loadCommand(args []string) error {
+	fs := newFlagSet("load", "-file <csv> -obj <object> [-op upsert] [-extid Id] [-mapping <file>] [-dryrun]\n       go-modifier load -process <name> [-conf process-conf.xml] [-dryrun]")
+	var f = fs.String("file", "", "the CSV to load")
+	var obj = fs.String("obj", "", "the object to load into")
+	var op = fs.String("op", "upsert", fmt.Sprintf("the operation, one of %v", strings.Join(loadOperations, ", ")))
+	var extId = fs.String("extid", "Id", "(upsert) the field to match existing records on")
+	var mappingFile = fs.String("mapping", "", "a .json or Data Loader .sdl mapping file to rename, drop, transform or add columns")
+	var process = fs.String("process", "", "run the named process (bean) from a Data Loader process-conf.xml")
+	var conf = fs.String("conf", "process-conf.xml", "(process) the Data Loader process-conf.xml")
+	var stage = fs.String("stage", "", "load a dataset from the staging store instead of -file, written kind:object[:dataset] e.g. generated:Account")
+	var run = fs.String("run", "", "(stage) the run to load the dataset from, defaults to the latest")
+	var dryRun = fs.Bool("dryrun", false, "write the mapped CSV but don't send it to Salesforce")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *process != "" {
+		var clash []string
+		fs.Visit(func(fl *flag.Flag) {
+			switch fl.Name {
+			case "file", "obj", "op", "extid", "mapping":
+				clash = append(clash, "-"+fl.Name)
+			}
+		})
+		if len(clash) > 0 {
+			return configError("%v can't be used with -process, they come from %v", strings.Join(clash, ", "), *conf)
+		}
+		p, err := mapping.LoadProcess(*conf, *process)
+		if err != nil {
+			return configError("%v", err)
+		}
+		*f, *obj, *op, *mappingFile = p.DataFile, p.Object, p.Operation, p.MappingFile
+		if p.ExternalId != "" {
+			*extId = p.ExternalId
+		}
+	}
+	var kind, stagedObj, dataset string
+	if *stage != "" {
+		if *f != "" {
+			return configError("-file and -stage can't be used together")
+		}
+		var ok bool
+		if kind, stagedObj, ok = strings.Cut(*stage, ":"); !ok || stagedObj == "" {
+			return configError("-stage is written kind:object[:dataset], e.g. generated:Account")
+		}
+		stagedObj, dataset, _ = strings.Cut(stagedObj, ":")
+		if *obj == "" {
+			*obj = stagedObj
+		}
+	} else if *f == "" {
+		return configError("-file and -obj (or -process or -stage) are required")
+	}
+	if *obj == "" {
+		return configError("-obj is required")
+	}
+	operation, ok := loadOperation(*op)
+	if !ok {
+		return configError("-op must be one of %v", strings.Join(loadOperations, ", "))
+	}
+	if *stage == "" {
+		if _, err := os.Stat(*f); err != nil {
+			return configError("%v", err)
+		}
+	}
+	if *mappingFile != "" {
+		// check the mapping before we do anything else
+		if _, err := mapping.Load(*mappingFile); err != nil {
+			return configError("%v", err)
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *stage != "" {
+		if *f, err = unstage(cfg, *run, kind, stagedObj, dataset); err != nil {
+			return err
+		}
+	}
+
+	path := *f
+	if *mappingFile != "" {
+		if path, err = sforce.MapCSV(cfg, *f, *obj, *mappingFile); err != nil {
+			return err
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+	c, err := login(cfg)
+	if err != nil {
+		return err
+	}
+	return sforce.IngestCSV(cfg, c, path, *obj, operation, *extId)
+}
+
+// returns the operation as the bulk api spells it
+func loadOperation(op string) (string, bool) {
+	for _, o := range loadOperations {
+		if strings.EqualFold(o, op) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// writes a dataset from the staging store to a CSV in the data directory and returns its path
+func unstage(cfg *config.Config, run string, kind string, obj string, dataset string) (string, error) {
+	s, err := staging.Get(cfg)
+	if err != nil {
+		return "", err
+	}
+	if s == nil {
+		return "", configError("-stage needs STAGING_DB to be set")
+	}
+	data, err := s.Read(run, kind, obj, dataset)
+	if err != nil {
+		return "", err
+	}
+	path, err := file.BuildFilePath(fmt.Sprintf("%v-%v-staged.csv", obj, kind), cfg)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Loading %d staged %v %v rows", len(data)-1, kind, obj)
+	return file.WriteCsv(path, data)
+}
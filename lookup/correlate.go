@@ -0,0 +1,99 @@
+package lookup
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+	A Correlation sets a field from the parent record a lookup points at, so
+	children look like they belong to their parent.
+
+	Rules are written as Field=expression where the expression is one of
+
+	AccountId.BillingCity               copy the parent's value
+	emaildomain(AccountId.Website)      keep the local part of the email, use the parent website's domain
+	prefix(AccountId.Name)              parent value, a dash, then the generated value
+	suffix(AccountId.Name)              generated value, a dash, then the parent value
+*/
+type Correlation struct {
+	Field       string // field on the child that is set
+	Lookup      string // lookup field on the child that points at the parent
+	ParentField string // field on the parent the value comes from
+	Func        string // copy, emaildomain, prefix or suffix
+}
+
+var correlationExpr = regexp.MustCompile(`^(\w+)\.(\w+)$`)
+var correlationFuncExpr = regexp.MustCompile(`^(\w+)\(\s*(\w+)\.(\w+)\s*\)$`)
+
+// parses the rules for one object, keyed by the child field name.
+// Rules are returned sorted by field so they are applied in a stable order.
+func ParseCorrelations(rules map[string]string) ([]Correlation, error) {
+	var cs []Correlation
+	for field, expr := range rules {
+		expr = strings.TrimSpace(expr)
+		var c Correlation
+		if m := correlationExpr.FindStringSubmatch(expr); m != nil {
+			c = Correlation{Field: field, Lookup: m[1], ParentField: m[2], Func: "copy"}
+		} else if m := correlationFuncExpr.FindStringSubmatch(expr); m != nil {
+			c = Correlation{Field: field, Lookup: m[2], ParentField: m[3], Func: strings.ToLower(m[1])}
+		} else {
+			return nil, fmt.Errorf("unable to parse correlation %v=%v", field, expr)
+		}
+		switch c.Func {
+		case "copy", "emaildomain", "prefix", "suffix":
+		default:
+			return nil, fmt.Errorf("unknown correlation function %v in %v=%v", c.Func, field, expr)
+		}
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].Field < cs[j].Field })
+	return cs, nil
+}
+
+// returns the new value for the child field given its current value and the parent's value.
+// A blank parent value leaves the child untouched.
+func (c Correlation) Apply(current string, parent string) string {
+	if parent == "" {
+		return current
+	}
+	switch c.Func {
+	case "emaildomain":
+		domain := Domain(parent)
+		if domain == "" {
+			return current
+		}
+		local, _, _ := strings.Cut(current, "@")
+		if local == "" {
+			local = "info"
+		}
+		return fmt.Sprintf("%v@%v", local, domain)
+	case "prefix":
+		if current == "" {
+			return parent
+		}
+		return fmt.Sprintf("%v - %v", parent, current)
+	case "suffix":
+		if current == "" {
+			return parent
+		}
+		return fmt.Sprintf("%v - %v", current, parent)
+	}
+	return parent
+}
+
+// returns the host of a website without the www, e.g. https://www.acme.com/about gives acme.com
+func Domain(website string) string {
+	website = strings.TrimSpace(website)
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+	u, err := url.Parse(website)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
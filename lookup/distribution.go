@@ -0,0 +1,306 @@
+package lookup
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+A Distribution decides how many child rows each parent record receives
+when we populate a lookup column.
+
+Specs are written as name[:args] and can be prefixed with atleastone+
+so every parent gets one child before the rest are distributed.
+
+uniform            every row picks a random parent (the original behaviour)
+fixed:3            every parent gets 3 children, rows past 3 per parent are left blank
+range:1-5          every parent gets between 1 and 5 children
+poisson:2.5        children per parent follow a poisson distribution
+zipf:1.2           parents are ranked, the top ranks get most of the children
+pareto:1.5         long tail where a few parents get most of the children
+atleastone         every parent gets one child, the rest are uniform
+atleastone+zipf:1  every parent gets one child, the rest follow zipf
+
+An Assign returns a value for every row. A row that can't be given a parent, because there are
+no parents or (with fixed) every parent has its share, is left blank.
+*/
+type Distribution interface {
+	Assign(rows int, parents []string) []string
+}
+
+// each row picks a random parent
+type Uniform struct{}
+
+// every parent gets exactly N children
+type Fixed struct {
+	N int
+}
+
+// every parent gets between Min and Max children
+type Range struct {
+	Min int
+	Max int
+}
+
+// children per parent follow a poisson distribution with mean Lambda
+type Poisson struct {
+	Lambda float64
+}
+
+// parents are ranked and weighted 1/rank^S
+type Zipf struct {
+	S float64
+}
+
+// parents are weighted from a pareto distribution with shape Alpha
+type Pareto struct {
+	Alpha float64
+}
+
+// every parent gets one child before Then distributes the remaining rows
+type AtLeastOne struct {
+	Then Distribution
+}
+
+// parses a spec such as "poisson:3" or "atleastone+zipf:1.2"
+func ParseDistribution(spec string) (Distribution, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if strings.HasPrefix(spec, "atleastone") {
+		rest := strings.TrimPrefix(spec, "atleastone")
+		if rest == "" {
+			return AtLeastOne{Then: Uniform{}}, nil
+		}
+		if !strings.HasPrefix(rest, "+") {
+			return nil, fmt.Errorf("unknown distribution %v", spec)
+		}
+		then, err := ParseDistribution(rest[1:])
+		if err != nil {
+			return nil, err
+		}
+		return AtLeastOne{Then: then}, nil
+	}
+
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "", "uniform":
+		return Uniform{}, nil
+	case "fixed":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("fixed distribution needs a positive count [%v]", spec)
+		}
+		return Fixed{N: n}, nil
+	case "range":
+		lo, hi, ok := strings.Cut(arg, "-")
+		min, err1 := strconv.Atoi(lo)
+		max, err2 := strconv.Atoi(hi)
+		if !ok || err1 != nil || err2 != nil || min < 0 || max < min || max == 0 {
+			return nil, fmt.Errorf("range distribution needs min-max [%v]", spec)
+		}
+		return Range{Min: min, Max: max}, nil
+	case "poisson":
+		l, err := strconv.ParseFloat(arg, 64)
+		if err != nil || l <= 0 {
+			return nil, fmt.Errorf("poisson distribution needs a positive mean [%v]", spec)
+		}
+		return Poisson{Lambda: l}, nil
+	case "zipf":
+		s, err := strconv.ParseFloat(arg, 64)
+		if err != nil || s <= 0 {
+			return nil, fmt.Errorf("zipf distribution needs a positive exponent [%v]", spec)
+		}
+		return Zipf{S: s}, nil
+	case "pareto":
+		a, err := strconv.ParseFloat(arg, 64)
+		if err != nil || a <= 0 {
+			return nil, fmt.Errorf("pareto distribution needs a positive shape [%v]", spec)
+		}
+		return Pareto{Alpha: a}, nil
+	}
+	return nil, fmt.Errorf("unknown distribution %v", spec)
+}
+
+func (u Uniform) Assign(rows int, parents []string) []string {
+	vals := make([]string, rows)
+	if len(parents) == 0 {
+		return vals
+	}
+	for i := range vals {
+		vals[i] = parents[random.Intn(len(parents))]
+	}
+	return vals
+}
+
+// no parent gets more than N children, rows past N for every parent are left blank
+func (f Fixed) Assign(rows int, parents []string) []string {
+	return assignCounts(rows, parents, func() int { return f.N }, f.N)
+}
+
+// no parent gets more than Max children, rows past Max for every parent are left blank
+func (r Range) Assign(rows int, parents []string) []string {
+	return assignCounts(rows, parents, func() int { return r.Min + random.Intn(r.Max-r.Min+1) }, r.Max)
+}
+
+func (p Poisson) Assign(rows int, parents []string) []string {
+	return assignCounts(rows, parents, func() int { return poisson(p.Lambda) }, 0)
+}
+
+func (z Zipf) Assign(rows int, parents []string) []string {
+	if len(parents) == 0 {
+		return make([]string, rows)
+	}
+	order := random.Perm(len(parents))
+	weights := make([]float64, len(parents))
+	for rank, i := range order {
+		weights[i] = 1 / math.Pow(float64(rank+1), z.S)
+	}
+	return assignWeighted(rows, parents, weights)
+}
+
+func (p Pareto) Assign(rows int, parents []string) []string {
+	if len(parents) == 0 {
+		return make([]string, rows)
+	}
+	weights := make([]float64, len(parents))
+	for i := range weights {
+		// inverse transform sampling with a minimum of 1
+		weights[i] = 1 / math.Pow(1-random.Float64(), 1/p.Alpha)
+	}
+	return assignWeighted(rows, parents, weights)
+}
+
+func (a AtLeastOne) Assign(rows int, parents []string) []string {
+	var vals []string
+	for _, i := range random.Perm(len(parents)) {
+		if len(vals) == rows {
+			break
+		}
+		vals = append(vals, parents[i])
+	}
+	if rows > len(vals) {
+		then := a.Then
+		if then == nil {
+			then = Uniform{}
+		}
+		vals = append(vals, then.Assign(rows-len(vals), parents)...)
+	}
+	shuffle(vals)
+	return vals
+}
+
+// walks the parents in a random order giving each one next() children until
+// all the rows are used. If every parent has had its share and there are still
+// rows left we go around again, unless max (when more than 0) is the most a parent
+// can have and they all have it. Rows no parent can take are left blank, as are
+// all of them without parents.
+func assignCounts(rows int, parents []string, next func() int, max int) []string {
+	if len(parents) == 0 {
+		return make([]string, rows)
+	}
+	vals := make([]string, 0, rows)
+	counts := make([]int, len(parents))
+	for len(vals) < rows && (max <= 0 || len(vals) < max*len(parents)) {
+		for _, i := range random.Perm(len(parents)) {
+			for n := next(); n > 0 && len(vals) < rows && (max <= 0 || counts[i] < max); n-- {
+				vals = append(vals, parents[i])
+				counts[i]++
+			}
+			if len(vals) == rows {
+				break
+			}
+		}
+	}
+	shuffle(vals)
+	return append(vals, make([]string, rows-len(vals))...)
+}
+
+// each row picks a parent with a probability proportional to its weight
+func assignWeighted(rows int, parents []string, weights []float64) []string {
+	cumulative := make([]float64, len(weights))
+	var total float64
+	for i, w := range weights {
+		total += w
+		cumulative[i] = total
+	}
+	vals := make([]string, rows)
+	for i := range vals {
+		r := random.Float64() * total
+		vals[i] = parents[sort.SearchFloat64s(cumulative, r)]
+	}
+	return vals
+}
+
+// knuth for small means, normal approximation for the large ones
+func poisson(lambda float64) int {
+	if lambda > 30 {
+		n := int(math.Round(random.NormFloat64()*math.Sqrt(lambda) + lambda))
+		if n < 0 {
+			return 0
+		}
+		return n
+	}
+	l := math.Exp(-lambda)
+	k := 0
+	p := 1.0
+	for {
+		p *= random.Float64()
+		if p <= l {
+			return k
+		}
+		k++
+	}
+}
+
+func shuffle(vals []string) {
+	random.Shuffle(len(vals), func(i, j int) { vals[i], vals[j] = vals[j], vals[i] })
+}
+
+// returns how many of the values were left blank
+func Unassigned(vals []string) int {
+	var n int
+	for _, v := range vals {
+		if v == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// returns a printable histogram of how many parents ended up with each number of children.
+func Histogram(parents []string, assigned []string) string {
+	perParent := make(map[string]int, len(parents))
+	for _, p := range parents {
+		perParent[p] = 0
+	}
+	for _, a := range assigned {
+		if a != "" {
+			perParent[a]++
+		}
+	}
+	buckets := make(map[int]int)
+	var max int
+	for _, n := range perParent {
+		buckets[n]++
+		if buckets[n] > max {
+			max = buckets[n]
+		}
+	}
+	var keys []int
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	var sb strings.Builder
+	sb.WriteString("children | parents\n")
+	for _, k := range keys {
+		bar := int(math.Ceil(float64(buckets[k]) / float64(max) * 50))
+		fmt.Fprintf(&sb, "%8d | %-7d %v\n", k, buckets[k], strings.Repeat("#", bar))
+	}
+	return sb.String()
+}
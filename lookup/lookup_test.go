@@ -0,0 +1,144 @@
+package lookup
+
+import (
+	"log"
+	"testing"
+)
+
+func TestParseDistribution(t *testing.T) {
+	good := []string{"uniform", "fixed:3", "range:1-5", "poisson:2.5", "zipf:1.2", "pareto:1.5", "atleastone", "atleastone+zipf:1"}
+	for _, s := range good {
+		if _, err := ParseDistribution(s); err != nil {
+			t.Errorf("%v : %v", s, err)
+		}
+	}
+	bad := []string{"fixed", "range:5-1", "poisson:-1", "atleastone-zipf", "normal:3"}
+	for _, s := range bad {
+		if _, err := ParseDistribution(s); err == nil {
+			t.Errorf("expected an error for %v", s)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	parents := []string{"a", "b", "c", "d", "e"}
+	dists := []string{"uniform", "fixed:3", "range:1-5", "poisson:2.5", "zipf:1.2", "pareto:1.5", "atleastone+zipf:2"}
+	for _, s := range dists {
+		d, _ := ParseDistribution(s)
+		vals := d.Assign(12, parents)
+		if len(vals) != 12 {
+			t.Errorf("%v assigned %d values, wanted 12", s, len(vals))
+		}
+		log.Printf("%v\n%v", s, Histogram(parents, vals))
+	}
+}
+
+func TestFixedAndAtLeastOne(t *testing.T) {
+	parents := []string{"a", "b", "c", "d"}
+	counts := make(map[string]int)
+	for _, v := range (Fixed{N: 3}).Assign(12, parents) {
+		counts[v]++
+	}
+	for _, p := range parents {
+		if counts[p] != 3 {
+			t.Errorf("%v has %d children, wanted 3", p, counts[p])
+		}
+	}
+
+	counts = make(map[string]int)
+	for _, v := range (AtLeastOne{Then: Zipf{S: 3}}).Assign(6, parents) {
+		counts[v]++
+	}
+	for _, p := range parents {
+		if counts[p] == 0 {
+			t.Errorf("%v has no children", p)
+		}
+	}
+}
+
+func TestParseWeighted(t *testing.T) {
+	ws, err := ParseWeighted("Account:50, Opportunity:30,Case")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 3 || ws[0].Weight != 50 || ws[2].Object != "Case" || ws[2].Weight != 1 {
+		t.Errorf("unexpected weights %v", ws)
+	}
+	for _, s := range []string{"Account:x", "Account:0", ":5"} {
+		if _, err := ParseWeighted(s); err == nil {
+			t.Errorf("expected an error for %v", s)
+		}
+	}
+
+	targets := AssignTargets(100, []Weighted{{Object: "Contact", Weight: 1}, {Object: "Lead", Weight: 0}})
+	for _, target := range targets {
+		if target != "Contact" {
+			t.Fatalf("picked %v which has no weight", target)
+		}
+	}
+}
+
+func TestCorrelations(t *testing.T) {
+	cs, err := ParseCorrelations(map[string]string{
+		"MailingCity": "AccountId.BillingCity",
+		"Email":       "emaildomain(AccountId.Website)",
+		"Name":        "prefix( AccountId.Name )",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sorted by field
+	if cs[0].Field != "Email" || cs[1].Field != "MailingCity" || cs[2].Field != "Name" {
+		t.Fatalf("unexpected order %v", cs)
+	}
+	if v := cs[0].Apply("jsmith@example.org", "https://www.acme.com/about"); v != "jsmith@acme.com" {
+		t.Errorf("emaildomain gave %v", v)
+	}
+	if v := cs[1].Apply("Paris", "Sydney"); v != "Sydney" {
+		t.Errorf("copy gave %v", v)
+	}
+	if v := cs[1].Apply("Paris", ""); v != "Paris" {
+		t.Errorf("copy of a blank parent gave %v", v)
+	}
+	if v := cs[2].Apply("Upgrade", "Acme"); v != "Acme - Upgrade" {
+		t.Errorf("prefix gave %v", v)
+	}
+	for _, bad := range []string{"BillingCity", "upper(AccountId.Name)", "prefix(AccountId.Name"} {
+		if _, err := ParseCorrelations(map[string]string{"Name": bad}); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestAssignShortfall(t *testing.T) {
+	dists := []string{"uniform", "fixed:3", "range:1-5", "poisson:2.5", "zipf:1.2", "pareto:1.5", "atleastone+range:1-2"}
+	for _, s := range dists {
+		d, _ := ParseDistribution(s)
+		vals := d.Assign(5, nil)
+		if len(vals) != 5 || Unassigned(vals) != 5 {
+			t.Errorf("%v without parents should leave 5 blank rows, got %q", s, vals)
+		}
+	}
+	vals := (Fixed{N: 2}).Assign(10, []string{"a", "b", "c"})
+	counts := make(map[string]int)
+	for _, v := range vals {
+		counts[v]++
+	}
+	if len(vals) != 10 || counts[""] != 4 || counts["a"] != 2 || counts["b"] != 2 || counts["c"] != 2 {
+		t.Errorf("fixed:2 across 3 parents should fill 6 rows and leave 4 blank, got %v", counts)
+	}
+	parents := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	vals = (Range{Min: 1, Max: 3}).Assign(100, parents)
+	counts = make(map[string]int)
+	for _, v := range vals {
+		counts[v]++
+	}
+	for _, p := range parents {
+		if counts[p] < 1 || counts[p] > 3 {
+			t.Errorf("range:1-3 gave %v %d children", p, counts[p])
+		}
+	}
+	if len(vals) != 100 || Unassigned(vals) != 70 {
+		t.Errorf("range:1-3 across 10 parents should fill 30 rows and leave 70 blank, got %d blank", Unassigned(vals))
+	}
+}
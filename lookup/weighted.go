@@ -0,0 +1,68 @@
+package lookup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+// an object that a polymorphic lookup can point at and how often it should be picked
+type Weighted struct {
+	Object string
+	Weight int
+}
+
+// parses a list such as "Account:50,Opportunity:30,Case:20".
+// An object without a weight gets a weight of 1.
+func ParseWeighted(spec string) ([]Weighted, error) {
+	var ws []Weighted
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		obj, weight, ok := strings.Cut(entry, ":")
+		w := Weighted{Object: strings.TrimSpace(obj), Weight: 1}
+		if ok {
+			n, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("weight for %v must be a positive number [%v]", obj, entry)
+			}
+			w.Weight = n
+		}
+		if w.Object == "" {
+			return nil, fmt.Errorf("missing object name in [%v]", spec)
+		}
+		ws = append(ws, w)
+	}
+	var total int
+	for _, w := range ws {
+		total += w.Weight
+	}
+	if len(ws) > 0 && total == 0 {
+		return nil, fmt.Errorf("at least one weight must be greater than zero [%v]", spec)
+	}
+	return ws, nil
+}
+
+// picks an object for each row in proportion to the weights
+func AssignTargets(rows int, ws []Weighted) []string {
+	var total int
+	for _, w := range ws {
+		total += w.Weight
+	}
+	targets := make([]string, rows)
+	for i := range targets {
+		r := random.Intn(total)
+		for _, w := range ws {
+			if r < w.Weight {
+				targets[i] = w.Object
+				break
+			}
+			r -= w.Weight
+		}
+	}
+	return targets
+}
@@ -0,0 +1,316 @@
+package lorem
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+	Locale packs, the names, places and formats that make a record look like it comes from a country.
+	Each is embedded from locales/<code>.json.
+
+	The locales a run uses are set once with SetLocales, either one locale or a weighted mix such as
+	en_US:60,de_DE:20,fr_FR:20. Values that belong together (a contact's name, street, city and postal
+	code) should come from the same *Locale, pick one per record with PickLocale or follow the record's
+	country with ForCountry.
+*/
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+type Locale struct {
+	Code            string   `json:"code"`
+	Country         string   `json:"country"`     // as Salesforce's country picklist names it
+	CountryCode     string   `json:"countryCode"` // ISO 3166 alpha-2
+	NameFormat      string   `json:"nameFormat"`  // {first} and {last}
+	FirstNames      []string `json:"firstNames"`
+	LastNames       []string `json:"lastNames"`
+	Streets         []string `json:"streets"`
+	StreetFormat    string   `json:"streetFormat"` // {street} and {number}
+	Cities          []string `json:"cities"`       // City|State
+	PostalFormat    string   `json:"postalCode"`   // as Sequence takes it
+	CallingCode     string   `json:"callingCode"`  // the country's E.164 calling code
+	TrunkPrefix     string   `json:"trunkPrefix"`  // dialled before a national number, dropped from E.164
+	PhoneFormats    []string `json:"phoneFormats"` // national numbers as Sequence takes them, {area} is one of PhoneAreas
+	PhoneAreas      []string `json:"phoneAreas"`
+	CompanyFormat   string   `json:"companyFormat"` // {name} and {suffix}
+	CompanySuffixes []string `json:"companySuffixes"`
+	// names written in latin letters, for emails, when the names aren't
+	AsciiFirstNames []string `json:"asciiFirstNames"`
+	AsciiLastNames  []string `json:"asciiLastNames"`
+}
+
+const DefaultLocale = "en_US"
+
+var (
+	localeMu sync.Mutex
+	locales  map[string]*Locale
+	mix      []weightedLocale
+)
+
+type weightedLocale struct {
+	locale *Locale
+	weight int
+}
+
+// loads the embedded packs the first time they are needed
+func loadLocales() map[string]*Locale {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	if locales != nil {
+		return locales
+	}
+	locales = make(map[string]*Locale)
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, e := range entries {
+		b, err := localeFiles.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var l Locale
+		if err := json.Unmarshal(b, &l); err != nil {
+			panic(fmt.Sprintf("locale %v : %v", e.Name(), err))
+		}
+		locales[strings.ToLower(l.Code)] = &l
+	}
+	return locales
+}
+
+// returns the codes of the locales there are packs for
+func Locales() []string {
+	var codes []string
+	for _, l := range loadLocales() {
+		codes = append(codes, l.Code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// returns the locale, en_US, en-us and EN_US are all the same one
+func GetLocale(code string) (*Locale, error) {
+	l, ok := loadLocales()[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", "_"))]
+	if !ok {
+		return nil, fmt.Errorf("no locale %v, use one of %v", code, strings.Join(Locales(), ", "))
+	}
+	return l, nil
+}
+
+// sets the locales the run picks from, a locale or a weighted list such as en_US:60,de_DE:40.
+// Blank is the default, en_US
+func SetLocales(spec string) error {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultLocale
+	}
+	var ws []weightedLocale
+	var total int
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		code, weight, ok := strings.Cut(entry, ":")
+		l, err := GetLocale(code)
+		if err != nil {
+			return err
+		}
+		w := weightedLocale{locale: l, weight: 1}
+		if ok {
+			n, err := strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil || n < 0 {
+				return fmt.Errorf("weight for %v must be a positive number [%v]", code, entry)
+			}
+			w.weight = n
+		}
+		total += w.weight
+		ws = append(ws, w)
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one locale needs a weight greater than zero [%v]", spec)
+	}
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	mix = ws
+	return nil
+}
+
+// returns a locale from those the run uses, in proportion to their weights
+func PickLocale() *Locale {
+	localeMu.Lock()
+	ws := mix
+	localeMu.Unlock()
+	if len(ws) == 0 {
+		l, _ := GetLocale(DefaultLocale)
+		return l
+	}
+	var total int
+	for _, w := range ws {
+		total += w.weight
+	}
+	r := random.Intn(total)
+	for _, w := range ws {
+		if r < w.weight {
+			return w.locale
+		}
+		r -= w.weight
+	}
+	return ws[len(ws)-1].locale
+}
+
+// returns the locale of a country, by name or ISO code. A country we don't have a pack for,
+// or a blank one, picks from the run's locales
+func ForCountry(country string) *Locale {
+	c := strings.TrimSpace(country)
+	if c != "" {
+		for _, l := range loadLocales() {
+			if strings.EqualFold(c, l.Country) || strings.EqualFold(c, l.CountryCode) {
+				return l
+			}
+		}
+		// the names Salesforce and people also use
+		switch strings.ToLower(c) {
+		case "usa", "united states of america":
+			return ForCountry("US")
+		case "uk", "great britain", "england", "scotland", "wales":
+			return ForCountry("GB")
+		case "deutschland":
+			return ForCountry("DE")
+		case "brasil":
+			return ForCountry("BR")
+		}
+	}
+	return PickLocale()
+}
+
+func pick(from []string) string {
+	return from[random.Intn(len(from))]
+}
+
+func (l *Locale) FirstName() string {
+	return pick(l.FirstNames)
+}
+
+func (l *Locale) LastName() string {
+	return pick(l.LastNames)
+}
+
+// the first and last name in the order the locale writes them
+func (l *Locale) FullName() string {
+	return strings.NewReplacer("{first}", l.FirstName(), "{last}", l.LastName()).Replace(l.NameFormat)
+}
+
+// the name of a street, without a number
+func (l *Locale) StreetName() string {
+	return pick(l.Streets)
+}
+
+// the first line of an address, with a house number where the locale puts it
+func (l *Locale) Street() string {
+	s := strings.Replace(l.StreetFormat, "{street}", pick(l.Streets), 1)
+	for strings.Contains(s, "{number}") {
+		n := random.Intn(199) + 1
+		if strings.Count(s, "{number}") > 1 {
+			// the block numbers of a japanese address are small
+			n = random.Intn(9) + 1
+		}
+		s = strings.Replace(s, "{number}", strconv.Itoa(n), 1)
+	}
+	return s
+}
+
+func (l *Locale) City() string {
+	city, _, _ := strings.Cut(pick(l.Cities), "|")
+	return city
+}
+
+func (l *Locale) State() string {
+	_, state, _ := strings.Cut(pick(l.Cities), "|")
+	return state
+}
+
+// the locale for one record, its city is chosen so the city and state of the record agree
+func (l *Locale) Record() *Locale {
+	r := *l
+	r.Cities = []string{pick(l.Cities)}
+	return &r
+}
+
+// a city and the state it is in
+func (l *Locale) CityAndState() (string, string) {
+	city, state, _ := strings.Cut(pick(l.Cities), "|")
+	return city, state
+}
+
+func (l *Locale) PostalCode() string {
+	return Sequence(l.PostalFormat)
+}
+
+func (l *Locale) Company() string {
+	return strings.NewReplacer("{name}", l.LastName(), "{suffix}", pick(l.CompanySuffixes)).Replace(l.CompanyFormat)
+}
+
+// an email address for a person of the locale, at one of the example domains
+func (l *Locale) Email() string {
+	first, last := l.FirstNames, l.LastNames
+	if len(l.AsciiFirstNames) > 0 {
+		first, last = l.AsciiFirstNames, l.AsciiLastNames
+	}
+	domains := []string{"example.com", "example.net", "example.org"}
+	return fmt.Sprintf("%v.%v%d@%v", Ascii(pick(first)), Ascii(pick(last)), random.Intn(100), pick(domains))
+}
+
+// the letters of the packs with accents, and what they are without them
+var unaccent = strings.NewReplacer(
+	"ß", "ss", "ä", "ae", "ö", "oe", "ü", "ue", "à", "a", "á", "a", "â", "a", "ã", "a", "ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "í", "i", "î", "i", "ï", "i", "ı", "i", "ó", "o", "ô", "o",
+	"õ", "o", "ş", "s", "ù", "u", "ú", "u", "û", "u", "ÿ", "y")
+
+// the name lower case, without accents and anything but a-z, as an email address would have it
+func Ascii(s string) string {
+	var sb strings.Builder
+	for _, c := range unaccent.Replace(strings.ToLower(s)) {
+		if c >= 'a' && c <= 'z' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// values from the run's locales, each call can pick a different one
+
+func FirstName() string {
+	return PickLocale().FirstName()
+}
+
+func LastName() string {
+	return PickLocale().LastName()
+}
+
+func FullName() string {
+	return PickLocale().FullName()
+}
+
+func Street() string {
+	return PickLocale().Street()
+}
+
+func City() string {
+	return PickLocale().City()
+}
+
+func State() string {
+	return PickLocale().State()
+}
+
+func PostalCode() string {
+	return PickLocale().PostalCode()
+}
+
+func Company() string {
+	return PickLocale().Company()
+}
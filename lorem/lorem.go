@@ -5,13 +5,14 @@
 package lorem
 
 import (
-	"math/rand"
 	"strings"
+
+	"github.com/troysellers/go-modifier/random"
 )
 
 // Generate a natural word len.
 func genWordLen() int {
-	f := rand.Float32() * 100
+	f := random.Float32() * 100
 	// a table of word lengths and their frequencies.
 	switch {
 	case f < 1.939:
@@ -51,7 +52,7 @@ func intRange(min, max int) int {
 	if min > max {
 		return intRange(max, min)
 	}
-	n := rand.Int() % (max - min)
+	n := random.Int() % (max - min)
 	return n + min
 }
 
@@ -63,7 +64,7 @@ func word(wordLen int) string {
 		wordLen = 13
 	}
 
-	n := rand.Int() % len(wordlist)
+	n := random.Int() % len(wordlist)
 	for {
 		if n >= len(wordlist)-1 {
 			n = 0
@@ -94,7 +95,7 @@ func Sentence(min, max int) string {
 
 		// maybe insert a comma, if there are currently < 2 commas, and
 		// the current word is not the last or first
-		if (rand.Int()%n == 0) && numcomma < maxcommas && i < n-1 && i > 2 {
+		if (random.Int()%n == 0) && numcomma < maxcommas && i < n-1 && i > 2 {
 			ws[i-1] += ","
 			numcomma += 1
 		}
@@ -160,3 +161,32 @@ func Host() string {
 func Email() string {
 	return Word(4, 10) + `@` + Host()
 }
+
+// Generate a string from a format as Mockaroo does, # is a digit, @ a lower case letter, ^ an upper case letter, * a digit or letter,
+// $ a digit or lower case letter and % a digit or upper case letter. Anything else is kept.
+func Sequence(format string) string {
+	const digits, lower, upper = "0123456789", "abcdefghijklmnopqrstuvwxyz", "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	var sb strings.Builder
+	for _, c := range format {
+		var from string
+		switch c {
+		case '#':
+			from = digits
+		case '@':
+			from = lower
+		case '^':
+			from = upper
+		case '*':
+			from = digits + lower + upper
+		case '$':
+			from = digits + lower
+		case '%':
+			from = digits + upper
+		default:
+			sb.WriteRune(c)
+			continue
+		}
+		sb.WriteByte(from[random.Intn(len(from))])
+	}
+	return sb.String()
+}
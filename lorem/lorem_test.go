@@ -6,6 +6,11 @@ package lorem
 
 import "testing"
 import "log"
+import "regexp"
+import "strings"
+import "os"
+
+import "github.com/troysellers/go-modifier/random"
 
 func TestAll(t *testing.T) {
 	for i := 1; i < 14; i++ {
@@ -20,3 +25,145 @@ func TestAll(t *testing.T) {
 		log.Print(Email())
 	}
 }
+
+func TestLocales(t *testing.T) {
+	postal := map[string]string{
+		"en_US": `^\d{5}$`,
+		"en_GB": `^[A-Z]{2}\d \d[A-Z]{2}$`,
+		"de_DE": `^\d{5}$`,
+		"fr_FR": `^\d{5}$`,
+		"ja_JP": `^\d{3}-\d{4}$`,
+		"pt_BR": `^\d{5}-\d{3}$`,
+	}
+	if len(Locales()) != len(postal) {
+		t.Errorf("expected %d locales, got %v", len(postal), Locales())
+	}
+	for code, pattern := range postal {
+		l, err := GetLocale(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Country == "" || len(l.CountryCode) != 2 {
+			t.Errorf("%v needs a country and its code", code)
+		}
+		for i := 0; i < 50; i++ {
+			if pc := l.PostalCode(); !regexp.MustCompile(pattern).MatchString(pc) {
+				t.Errorf("%v postal code %q doesn't match %v", code, pc, pattern)
+			}
+			if e := l.Email(); !regexp.MustCompile(`^[a-z]+\.[a-z]+\d*@example\.(com|net|org)$`).MatchString(e) {
+				t.Errorf("%v email %q", code, e)
+			}
+			if strings.Contains(l.FullName()+l.Street()+l.Company(), "{") {
+				t.Errorf("%v has a format that wasn't filled in", code)
+			}
+		}
+		r := l.Record()
+		if r.City() != r.City() || len(l.Cities) < 2 {
+			t.Errorf("%v a record should keep to one city", code)
+		}
+	}
+	if _, err := GetLocale("de-de"); err != nil {
+		t.Error(err)
+	}
+	for _, spec := range []string{"xx_XX", "en_US:x", "en_US:-1", "en_US:0"} {
+		if err := SetLocales(spec); err == nil {
+			t.Errorf("expected an error for %v", spec)
+		}
+	}
+	if err := SetLocales("de_DE:1,fr_FR:0"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if l := PickLocale(); l.Code != "de_DE" {
+			t.Errorf("picked %v, which has no weight", l.Code)
+		}
+	}
+	tests := map[string]string{"Japan": "ja_JP", "UK": "en_GB", "BR": "pt_BR", "France": "fr_FR", "": "de_DE", "Narnia": "de_DE"}
+	for country, code := range tests {
+		if l := ForCountry(country); l.Code != code {
+			t.Errorf("%q should be %v, got %v", country, code, l.Code)
+		}
+	}
+	SetLocales("")
+	if Ascii("Müller-Lüdenscheidt") != "muellerluedenscheidt" || Ascii("François") != "francois" {
+		t.Errorf("unexpected %v %v", Ascii("Müller-Lüdenscheidt"), Ascii("François"))
+	}
+}
+
+func TestMarkov(t *testing.T) {
+	m := DefaultMarkov()
+	if !m.Trained() {
+		t.Fatal("the default model isn't trained")
+	}
+	for i := 0; i < 50; i++ {
+		s := m.Sentence()
+		if s == "" || strings.Contains(s, start) || strings.Contains(s, end) {
+			t.Errorf("unexpected sentence %q", s)
+		}
+	}
+	dir := t.TempDir()
+	csvPath := dir + "/cases.csv"
+	os.WriteFile(csvPath, []byte("Id,Description\n1,\"The printer is broken. Please send help.\"\n2,The printer is jammed again.\n"), 0644)
+	if _, err := TrainCSV(csvPath, []string{"Subject"}, 2); err == nil {
+		t.Error("expected an error for a missing column")
+	}
+	m, err := TrainCSV(csvPath, []string{"description"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every sentence starts "The printer is" or "Please send help."
+	for i := 0; i < 20; i++ {
+		if s := m.Sentence(); !strings.HasPrefix(s, "The printer is ") && s != "Please send help." {
+			t.Errorf("unexpected sentence %q", s)
+		}
+	}
+	modelPath := dir + "/model.json"
+	if err := m.Save(modelPath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadMarkov(modelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Order != 2 || len(loaded.Chain) != len(m.Chain) {
+		t.Errorf("the loaded model differs from the saved one")
+	}
+	os.WriteFile(modelPath, []byte(`{"order":2,"chain":{}}`), 0644)
+	if _, err := LoadMarkov(modelPath); err == nil {
+		t.Error("expected an error for an untrained model")
+	}
+	random.SetSeed(7)
+	a := TextParagraph(3, 4)
+	random.SetSeed(7)
+	if b := TextParagraph(3, 4); a != b {
+		t.Errorf("the same seed made different text\n%v\n%v", a, b)
+	}
+}
+
+func TestPhone(t *testing.T) {
+	tests := map[string][]string{
+		"en_US": {`^\(\d{3}\) 555-01\d\d$`, `^\+1\d{3}55501\d\d$`},
+		"en_GB": {`^0\d{2,4} \d{3,6}( \d{4})?$`, `^\+44[1-9]\d{9}$`},
+		"de_DE": {`^0\d{2,3} \d{4,5} \d{3}$`, `^\+49[1-9]\d{9}$`},
+		"fr_FR": {`^0[1-6]( \d\d){4}$`, `^\+33[1-6]\d{8}$`},
+		"ja_JP": {`^0\d0?-\d{4}-\d{4}$`, `^\+81[1-9]\d{8,9}$`},
+		"pt_BR": {`^\(\d\d\) [39]\d{3,4}-\d{4}$`, `^\+55\d{10,11}$`},
+	}
+	defer SetPhoneFormat("")
+	for code, patterns := range tests {
+		l, _ := GetLocale(code)
+		for i, format := range []string{PhoneNational, PhoneE164} {
+			if err := SetPhoneFormat(format); err != nil {
+				t.Fatal(err)
+			}
+			for j := 0; j < 50; j++ {
+				if p := l.Phone(); !regexp.MustCompile(patterns[i]).MatchString(p) {
+					t.Errorf("%v %v phone %q doesn't match %v", code, format, p, patterns[i])
+				}
+			}
+		}
+	}
+	if err := SetPhoneFormat("international"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,226 @@
+package lorem
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+	A markov chain of words, so generated descriptions read like the text it was trained on rather
+	than latin. Each run of Order words is followed by one of the words that followed it in the
+	corpus, as often as it did, and a sentence ends where sentences in the corpus ended.
+
+	Train it from a CSV export of real text (case descriptions, task comments) with TrainCSV, save it
+	with Save and point TEXT_MODEL at the file. Without one a chain trained from the support text
+	embedded in corpus/support.txt is used.
+*/
+
+//go:embed corpus/support.txt
+var defaultCorpus string
+
+const (
+	DefaultOrder = 2
+	start        = "<s>"  // the words before the first word of a sentence
+	end          = "</s>" // follows the last word of a sentence
+	maxWords     = 60     // a chain that never ends a sentence stops here
+)
+
+type Markov struct {
+	Order int                 `json:"order"`
+	Chain map[string][]string `json:"chain"` // the words joined with a space, to the words that followed them
+}
+
+var (
+	markovMu sync.Mutex
+	model    *Markov
+)
+
+func NewMarkov(order int) *Markov {
+	if order < 1 {
+		order = DefaultOrder
+	}
+	return &Markov{Order: order, Chain: make(map[string][]string)}
+}
+
+// adds the sentences of the text to the chain
+func (m *Markov) Train(text string) {
+	for _, s := range sentences(text) {
+		prefix := make([]string, m.Order)
+		for i := range prefix {
+			prefix[i] = start
+		}
+		for _, w := range append(s, end) {
+			key := strings.Join(prefix, " ")
+			m.Chain[key] = append(m.Chain[key], w)
+			prefix = append(prefix[1:], w)
+		}
+	}
+}
+
+// splits text into sentences of words, a sentence ends with a word ending in . ! or ?
+func sentences(text string) [][]string {
+	var out [][]string
+	var s []string
+	for _, w := range strings.Fields(text) {
+		s = append(s, w)
+		if strings.ContainsAny(w[len(w)-1:], ".!?") {
+			out = append(out, s)
+			s = nil
+		}
+	}
+	if len(s) > 0 {
+		out = append(out, s)
+	}
+	return out
+}
+
+// true if the chain can start a sentence
+func (m *Markov) Trained() bool {
+	return len(m.Chain[strings.TrimSpace(strings.Repeat(start+" ", m.Order))]) > 0
+}
+
+// returns a sentence walked from the chain
+func (m *Markov) Sentence() string {
+	prefix := make([]string, m.Order)
+	for i := range prefix {
+		prefix[i] = start
+	}
+	var words []string
+	for len(words) < maxWords {
+		next := m.Chain[strings.Join(prefix, " ")]
+		if len(next) == 0 {
+			break
+		}
+		w := next[random.Intn(len(next))]
+		if w == end {
+			break
+		}
+		words = append(words, w)
+		prefix = append(prefix[1:], w)
+	}
+	return strings.Join(words, " ")
+}
+
+// returns between min and max sentences
+func (m *Markov) Paragraph(min, max int) string {
+	var ss []string
+	for i := intRange(min, max); i > 0; i-- {
+		ss = append(ss, m.Sentence())
+	}
+	return strings.Join(ss, " ")
+}
+
+// writes the chain to a json file
+func (m *Markov) Save(path string) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// reads a chain saved with Save
+func LoadMarkov(path string) (*Markov, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Markov
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%v isn't a text model : %v", path, err)
+	}
+	if m.Order < 1 || !m.Trained() {
+		return nil, fmt.Errorf("%v isn't a trained text model", path)
+	}
+	return &m, nil
+}
+
+// trains a chain from the columns of a CSV with a header row, each value is a text of its own
+func TrainCSV(path string, columns []string, order int) (*Markov, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the header of %v : %v", path, err)
+	}
+	var cols []int
+	for _, c := range columns {
+		i := -1
+		for j, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(c)) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("%v has no column %v", path, c)
+		}
+		cols = append(cols, i)
+	}
+	m := NewMarkov(order)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range cols {
+			if i < len(row) {
+				m.Train(row[i])
+			}
+		}
+	}
+	if !m.Trained() {
+		return nil, fmt.Errorf("no text in %v of %v", strings.Join(columns, ", "), path)
+	}
+	return m, nil
+}
+
+// returns a chain trained from the embedded support text
+func DefaultMarkov() *Markov {
+	m := NewMarkov(DefaultOrder)
+	for _, line := range strings.Split(defaultCorpus, "\n") {
+		m.Train(line)
+	}
+	return m
+}
+
+// sets the chain the run's text is made from, nil goes back to the default
+func SetMarkov(m *Markov) {
+	markovMu.Lock()
+	defer markovMu.Unlock()
+	model = m
+}
+
+func getMarkov() *Markov {
+	markovMu.Lock()
+	defer markovMu.Unlock()
+	if model == nil {
+		model = DefaultMarkov()
+	}
+	return model
+}
+
+// a sentence from the run's text model
+func TextSentence() string {
+	return getMarkov().Sentence()
+}
+
+// between min and max sentences from the run's text model
+func TextParagraph(min, max int) string {
+	return getMarkov().Paragraph(min, max)
+}
@@ -0,0 +1,83 @@
+package lorem
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+	Phone numbers that are valid for the locale's country, the right length and prefixes, written the
+	way the country writes them or as E.164.
+
+	Where a country keeps numbers aside for films and examples they are used, so a generated number
+	never rings anyone: 555-0100 to 555-0199 in any NANP area code, Ofcom's drama ranges for the UK,
+	the Bundesnetzagentur's for Germany and ARCEP's for France. Japan and Brazil don't have them, their
+	numbers are well formed but could be someone's.
+*/
+
+const (
+	PhoneNational = "national" // as the country writes them, (212) 555-0142 or 020 7946 0321
+	PhoneE164     = "e164"     // +12125550142
+)
+
+var (
+	phoneMu     sync.Mutex
+	phoneFormat = PhoneNational
+)
+
+// sets how phone numbers are written, national or e164. Blank is national
+func SetPhoneFormat(f string) error {
+	f = strings.ToLower(strings.TrimSpace(f))
+	switch f {
+	case "":
+		f = PhoneNational
+	case PhoneNational, PhoneE164:
+	default:
+		return fmt.Errorf("phone format must be %v or %v, not %v", PhoneNational, PhoneE164, f)
+	}
+	phoneMu.Lock()
+	defer phoneMu.Unlock()
+	phoneFormat = f
+	return nil
+}
+
+func getPhoneFormat() string {
+	phoneMu.Lock()
+	defer phoneMu.Unlock()
+	return phoneFormat
+}
+
+// a phone number of the locale written as the run writes them
+func (l *Locale) Phone() string {
+	n := l.NationalPhone()
+	if getPhoneFormat() == PhoneE164 {
+		return l.E164(n)
+	}
+	return n
+}
+
+// a phone number written as the country writes them
+func (l *Locale) NationalPhone() string {
+	f := pick(l.PhoneFormats)
+	if strings.Contains(f, "{area}") {
+		f = strings.Replace(f, "{area}", pick(l.PhoneAreas), 1)
+	}
+	return Sequence(f)
+}
+
+// the national number as E.164, the calling code and the digits without the trunk prefix
+func (l *Locale) E164(national string) string {
+	var digits strings.Builder
+	for _, c := range national {
+		if c >= '0' && c <= '9' {
+			digits.WriteRune(c)
+		}
+	}
+	return "+" + l.CallingCode + strings.TrimPrefix(digits.String(), l.TrunkPrefix)
+}
+
+// a phone number from one of the run's locales
+func Phone() string {
+	return PickLocale().Phone()
+}
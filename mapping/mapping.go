@@ -0,0 +1,169 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+A Mapping turns the columns of a CSV from somewhere else into the fields of a Salesforce object.
+
+	{
+		"dropUnmapped": false,
+		"fields": [
+			{"column": "Company Name", "field": "Name", "transform": "trim"},
+			{"column": "Phone Number", "field": "Phone", "transform": "digits"},
+			{"column": "Joined", "field": "CustomerSince__c", "transform": "date:02/01/2006"},
+			{"column": "Internal Notes", "drop": true},
+			{"field": "Type", "value": "Customer"}
+		]
+	}
+
+Columns without an entry keep their name unless dropUnmapped is set.
+An entry with a value and no column adds a constant, with a column the value is used when the column is blank.
+Transforms are applied in order and separated with | e.g. "trim|upper|truncate:40".
+*/
+type Mapping struct {
+	Fields       []Field `json:"fields"`
+	DropUnmapped bool    `json:"dropUnmapped"`
+}
+
+// maps one column, or a constant, to a field
+type Field struct {
+	Column    string  `json:"column"`
+	Field     string  `json:"field"`
+	Value     *string `json:"value"`
+	Drop      bool    `json:"drop"`
+	Transform string  `json:"transform"`
+}
+
+// reads a mapping file
+func Load(path string) (*Mapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var m Mapping
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("unable to read mapping %v : %v", path, err)
+		}
+		if err := m.check(); err != nil {
+			return nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return &m, nil
+	case ".sdl":
+		m, err := ParseSDL(b)
+		if err != nil {
+			return nil, fmt.Errorf("%v : %v", path, err)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown mapping file type %v, use .json or .sdl", path)
+}
+
+// checks every entry makes sense and the transforms parse
+func (m *Mapping) check() error {
+	for i, f := range m.Fields {
+		switch {
+		case f.Drop && f.Column == "":
+			return fmt.Errorf("entry %d drops a column but doesn't name it", i+1)
+		case f.Drop:
+			continue
+		case f.Field == "":
+			return fmt.Errorf("entry %d has no field", i+1)
+		case f.Column == "" && f.Value == nil:
+			return fmt.Errorf("%v needs a column or a value", f.Field)
+		}
+		if _, err := parseTransforms(f.Transform); err != nil {
+			return fmt.Errorf("%v : %v", f.Field, err)
+		}
+	}
+	return nil
+}
+
+// returns the data (header row first) with the columns renamed, dropped, transformed and the constants added
+func (m *Mapping) Apply(data [][]string) ([][]string, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	header := data[0]
+
+	// each output column reads from a source column (or nowhere) and runs the transforms
+	type output struct {
+		field      string
+		source     int
+		constant   *string
+		transforms []transform
+	}
+	var outputs []output
+	mapped := make(map[int]bool)
+	for _, f := range m.Fields {
+		src := -1
+		if f.Column != "" {
+			if src = indexOf(header, f.Column); src < 0 {
+				return nil, fmt.Errorf("column %v isn't in the file", f.Column)
+			}
+			mapped[src] = true
+		}
+		if f.Drop {
+			continue
+		}
+		ts, _ := parseTransforms(f.Transform)
+		outputs = append(outputs, output{field: f.Field, source: src, constant: f.Value, transforms: ts})
+	}
+	if !m.DropUnmapped {
+		for i, col := range header {
+			if !mapped[i] {
+				outputs = append(outputs, output{field: col, source: i})
+			}
+		}
+	}
+
+	result := [][]string{make([]string, len(outputs))}
+	seen := make(map[string]bool)
+	for i, o := range outputs {
+		if seen[strings.ToLower(o.field)] {
+			return nil, fmt.Errorf("%v is mapped more than once", o.field)
+		}
+		seen[strings.ToLower(o.field)] = true
+		result[0][i] = o.field
+	}
+	for r, row := range data[1:] {
+		out := make([]string, len(outputs))
+		for i, o := range outputs {
+			var v string
+			if o.source >= 0 && o.source < len(row) {
+				v = row[o.source]
+			}
+			if v == "" && o.constant != nil {
+				v = *o.constant
+			}
+			for _, t := range o.transforms {
+				var err error
+				if v, err = t(v); err != nil {
+					return nil, fmt.Errorf("row %d %v : %v", r+1, o.field, err)
+				}
+			}
+			out[i] = v
+		}
+		result = append(result, out)
+	}
+	return result, nil
+}
+
+func indexOf(header []string, col string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(col)) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,197 @@
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApply(t *testing.T) {
+	customer := "Customer"
+	m := &Mapping{Fields: []Field{
+		{Column: "Company Name", Field: "Name", Transform: "trim|title"},
+		{Column: "Phone Number", Field: "Phone", Transform: "digits"},
+		{Column: "Joined", Field: "CustomerSince__c", Transform: "date:02/01/2006"},
+		{Column: "Notes", Drop: true},
+		{Field: "Type", Value: &customer},
+	}}
+	data := [][]string{
+		{"Company Name", "Phone Number", "Joined", "Notes", "Industry"},
+		{" acme  widgets ", "+61 (3) 9999-1234", "25/12/2020", "secret", "Retail"},
+		{"globex", "", "", "", ""},
+	}
+	got, err := m.Apply(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Name", "Phone", "CustomerSince__c", "Type", "Industry"},
+		{"Acme Widgets", "+61399991234", "2020-12-25", "Customer", "Retail"},
+		{"Globex", "", "", "Customer", ""},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	m.DropUnmapped = true
+	got, err = m.Apply(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got[0]) != 4 {
+		t.Errorf("expected Industry to be dropped, got %v", got[0])
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	data := [][]string{{"A", "B"}, {"1", "x"}}
+	tests := map[string]*Mapping{
+		"missing column":   {Fields: []Field{{Column: "C", Field: "Name"}}},
+		"mapped twice":     {Fields: []Field{{Column: "A", Field: "B"}, {Column: "B", Field: "B"}}},
+		"bad transform":    {Fields: []Field{{Column: "A", Field: "Name", Transform: "shout"}}},
+		"bad date":         {Fields: []Field{{Column: "B", Field: "Date", Transform: "date:2006-01-02"}}},
+		"no field":         {Fields: []Field{{Column: "A"}}},
+		"no column to use": {Fields: []Field{{Field: "Name"}}},
+	}
+	for name, m := range tests {
+		if _, err := m.Apply(data); err == nil {
+			t.Errorf("%v : expected an error", name)
+		}
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		spec, in, out string
+	}{
+		{"upper", "abc", "ABC"},
+		{"truncate:3", "abcdef", "abc"},
+		{"default:none", "", "none"},
+		{"prefix:ACC-|suffix:!", "1", "ACC-1!"},
+		{"prefix:ACC-", "", ""},
+		{"replace:-=", "a-b-c", "abc"},
+		{"bool", "Yes", "true"},
+		{"bool", "no", "false"},
+		{"datetime:2006-01-02 15:04", "2021-03-04 05:06", "2021-03-04T05:06:00.000Z"},
+	}
+	for _, tc := range tests {
+		ts, err := parseTransforms(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := tc.in
+		for _, tr := range ts {
+			if v, err = tr(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if v != tc.out {
+			t.Errorf("%v [%v] : expected %v got %v", tc.spec, tc.in, tc.out, v)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	if err := os.WriteFile(path, []byte(`{"fields":[{"column":"Co","field":"Name"},{"field":"Type","value":"Prospect"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Fields) != 2 || *m.Fields[1].Value != "Prospect" {
+		t.Errorf("loaded %+v", m)
+	}
+}
+
+func TestParseSDL(t *testing.T) {
+	sdl := `#Mapping values
+#Mon Jan 23 17:18:31 PST 2012
+Company\ Name=Name
+Phone=Phone, Fax
+ParentRef=Parent\:External_Id__c
+AcctRef=AccountId\:External_Id__c
+CustomRef=Region__c\:Code__c
+OwnerRef=Owner\:User.External_Id__c
+"Customer"=Type
+Unused=
+`
+	m, err := ParseSDL([]byte(sdl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := [][]string{
+		{"Company Name", "Phone", "ParentRef", "AcctRef", "CustomRef", "OwnerRef", "Unused", "Other"},
+		{"Acme", "555", "P-1", "A-1", "R-1", "U-1", "x", "y"},
+	}
+	got, err := m.Apply(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Name", "Phone", "Fax", "Parent.External_Id__c", "Account.External_Id__c", "Region__r.Code__c", "Owner:User.External_Id__c", "Type"},
+		{"Acme", "555", "555", "P-1", "A-1", "R-1", "U-1", "Customer"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestLoadProcess(t *testing.T) {
+	dir := t.TempDir()
+	conf := `<!DOCTYPE beans PUBLIC "-//SPRING//DTD BEAN//EN" "http://www.springframework.org/dtd/spring-beans.dtd">
+<beans>
+	<bean id="accountUpsert" class="com.salesforce.dataloader.process.ProcessRunner" singleton="false">
+		<description>upserts accounts</description>
+		<property name="name" value="accountUpsertProcess"/>
+		<property name="configOverrideMap">
+			<map>
+				<entry key="sfdc.entity" value="Account"/>
+				<entry key="process.operation" value="upsert"/>
+				<entry key="sfdc.externalIdField" value="External_Id__c"/>
+				<entry key="process.mappingFile" value="accountMap.sdl"/>
+				<entry key="dataAccess.name" value="/data/accounts.csv"/>
+				<entry key="dataAccess.type" value="csvRead"/>
+			</map>
+		</property>
+	</bean>
+	<bean id="accountExtract" class="com.salesforce.dataloader.process.ProcessRunner" singleton="false">
+		<property name="configOverrideMap">
+			<map>
+				<entry key="sfdc.entity" value="Account"/>
+				<entry key="process.operation" value="extract"/>
+			</map>
+		</property>
+	</bean>
+</beans>`
+	path := filepath.Join(dir, "process-conf.xml")
+	if err := os.WriteFile(path, []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"accountUpsert", "accountUpsertProcess"} {
+		p, err := LoadProcess(path, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &Process{
+			Name:        "accountUpsertProcess",
+			Object:      "Account",
+			Operation:   "upsert",
+			ExternalId:  "External_Id__c",
+			MappingFile: filepath.Join(dir, "accountMap.sdl"),
+			DataFile:    "/data/accounts.csv",
+		}
+		if diff := cmp.Diff(want, p); diff != "" {
+			t.Error(diff)
+		}
+	}
+	if _, err := LoadProcess(path, "accountExtract"); err == nil {
+		t.Error("expected an error for an extract process")
+	}
+	if _, err := LoadProcess(path, "nope"); err == nil {
+		t.Error("expected an error for a missing process")
+	}
+}
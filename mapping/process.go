@@ -0,0 +1,122 @@
+package mapping
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// a recurring load from a Data Loader process-conf.xml
+type Process struct {
+	Name        string
+	Object      string
+	Operation   string // as the Bulk API spells it
+	ExternalId  string
+	MappingFile string
+	DataFile    string
+}
+
+type beans struct {
+	Beans []bean `xml:"bean"`
+}
+
+type bean struct {
+	Id         string     `xml:"id,attr"`
+	Properties []property `xml:"property"`
+}
+
+type property struct {
+	Name    string  `xml:"name,attr"`
+	Value   string  `xml:"value,attr"`
+	Entries []entry `xml:"map>entry"`
+}
+
+type entry struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Data Loader operations and what the Bulk API calls them
+var processOperations = map[string]string{
+	"insert":      "insert",
+	"update":      "update",
+	"upsert":      "upsert",
+	"delete":      "delete",
+	"hard_delete": "hardDelete",
+}
+
+// reads the named process (the bean id or its name property) from a process-conf.xml.
+// Relative mapping and data files are relative to the directory of the conf file.
+func LoadProcess(path string, name string) (*Process, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var conf beans
+	if err := xml.Unmarshal(b, &conf); err != nil {
+		return nil, fmt.Errorf("unable to read %v : %v", path, err)
+	}
+	var names []string
+	for _, bn := range conf.Beans {
+		p := bn.process()
+		names = append(names, p.Name)
+		if bn.Id != name && p.Name != name {
+			continue
+		}
+		entries := bn.entries()
+		if t := entries["dataAccess.type"]; t != "" && !strings.EqualFold(t, "csvRead") {
+			return nil, fmt.Errorf("process %v reads from %v, only csvRead is supported", name, t)
+		}
+		op := entries["process.operation"]
+		if p.Operation = processOperations[strings.ToLower(op)]; p.Operation == "" {
+			if strings.HasPrefix(strings.ToLower(op), "extract") {
+				return nil, fmt.Errorf("process %v is an %v, use the export command instead", name, op)
+			}
+			return nil, fmt.Errorf("process %v has an unsupported operation [%v]", name, op)
+		}
+		dir := filepath.Dir(path)
+		p.Object = entries["sfdc.entity"]
+		p.ExternalId = entries["sfdc.externalIdField"]
+		p.MappingFile = relativeTo(dir, entries["process.mappingFile"])
+		p.DataFile = relativeTo(dir, entries["dataAccess.name"])
+		if p.Object == "" || p.DataFile == "" {
+			return nil, fmt.Errorf("process %v needs sfdc.entity and dataAccess.name", name)
+		}
+		return &p, nil
+	}
+	return nil, fmt.Errorf("no process named %v in %v, there is %v", name, path, strings.Join(names, ", "))
+}
+
+// the process with its name, the bean id unless there is a name property
+func (b bean) process() Process {
+	p := Process{Name: b.Id}
+	for _, prop := range b.Properties {
+		if prop.Name == "name" && prop.Value != "" {
+			p.Name = prop.Value
+		}
+	}
+	return p
+}
+
+// the entries of the configOverrideMap
+func (b bean) entries() map[string]string {
+	m := make(map[string]string)
+	for _, prop := range b.Properties {
+		if prop.Name != "configOverrideMap" {
+			continue
+		}
+		for _, e := range prop.Entries {
+			m[e.Key] = e.Value
+		}
+	}
+	return m
+}
+
+func relativeTo(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
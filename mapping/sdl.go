@@ -0,0 +1,103 @@
+package mapping
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+/*
+Reads a Data Loader .sdl mapping, a properties file of CSV column to Salesforce field
+
+#Mapping values
+Company\ Name=Name
+Phone=Phone, Fax
+ParentRef=Parent\:External_Id__c
+"Customer"=Type
+
+A quoted column is a constant, a comma separated list maps the column to several fields and
+Relationship:ExternalIdField sets a lookup by external Id, written as Relationship.ExternalIdField for the Bulk API.
+Lookups named by their field rather than the relationship (AccountId, Parent__c) are changed to the relationship name.
+As with Data Loader, columns not in the file aren't loaded.
+*/
+func ParseSDL(b []byte) (*Mapping, error) {
+	m := &Mapping{DropUnmapped: true}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	var n int
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		col, fields := splitProperty(line)
+		if col == "" {
+			return nil, fmt.Errorf("line %d has no column [%v]", n, line)
+		}
+		var constant *string
+		if len(col) > 1 && strings.HasPrefix(col, "\"") && strings.HasSuffix(col, "\"") {
+			v := col[1 : len(col)-1]
+			constant = &v
+			col = ""
+		}
+		for _, f := range strings.Split(fields, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			m.Fields = append(m.Fields, Field{Column: col, Field: bulkField(f), Value: constant})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// returns the Bulk API column for a Data Loader field.
+// Relationship:ExternalIdField becomes Relationship.ExternalIdField,
+// the polymorphic form Object:Relationship.Field is already what the Bulk API expects.
+func bulkField(f string) string {
+	rel, ext, ok := strings.Cut(f, ":")
+	if !ok || strings.Contains(ext, ".") {
+		return f
+	}
+	switch {
+	case strings.HasSuffix(rel, "__c"):
+		rel = strings.TrimSuffix(rel, "__c") + "__r"
+	case len(rel) > 2 && strings.HasSuffix(rel, "Id"):
+		rel = strings.TrimSuffix(rel, "Id")
+	}
+	return fmt.Sprintf("%v.%v", rel, ext)
+}
+
+// splits a properties line into its unescaped key and value.
+// The key ends at the first = or : that isn't escaped with a backslash.
+func splitProperty(line string) (string, string) {
+	var key strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' && i+1 < len(line) {
+			i++
+			key.WriteByte(line[i])
+			continue
+		}
+		if c == '=' || c == ':' {
+			return strings.TrimSpace(key.String()), strings.TrimSpace(unescape(line[i+1:]))
+		}
+		key.WriteByte(c)
+	}
+	return strings.TrimSpace(key.String()), ""
+}
+
+func unescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
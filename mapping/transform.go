@@ -0,0 +1,140 @@
+package mapping
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// changes a single value
+type transform func(string) (string, error)
+
+// the Salesforce formats dates are written in
+const dateFormat string = "2006-01-02"
+const dateTimeFormat string = "2006-01-02T15:04:05.000Z"
+
+/*
+trim, upper, lower, title    change the case or whitespace
+digits                       keep only the digits (and a leading +)
+bool                         yes/y/1/true become true, everything else false
+truncate:n                   cut to n characters
+default:value                use value when blank
+prefix:text / suffix:text    add text to values that aren't blank
+replace:old=new              replace all the occurrences of old
+date:layout                  read with a Go layout and write as a Salesforce date
+datetime:layout              read with a Go layout and write as a Salesforce datetime (UTC)
+*/
+func parseTransforms(spec string) ([]transform, error) {
+	var ts []transform
+	if strings.TrimSpace(spec) == "" {
+		return ts, nil
+	}
+	for _, s := range strings.Split(spec, "|") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(s), ":")
+		t, err := newTransform(strings.ToLower(name), arg)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+func newTransform(name string, arg string) (transform, error) {
+	switch name {
+	case "trim":
+		return func(v string) (string, error) { return strings.TrimSpace(v), nil }, nil
+	case "upper":
+		return func(v string) (string, error) { return strings.ToUpper(v), nil }, nil
+	case "lower":
+		return func(v string) (string, error) { return strings.ToLower(v), nil }, nil
+	case "title":
+		return func(v string) (string, error) { return title(v), nil }, nil
+	case "digits":
+		return func(v string) (string, error) {
+			var sb strings.Builder
+			for i, r := range strings.TrimSpace(v) {
+				if unicode.IsDigit(r) || (i == 0 && r == '+') {
+					sb.WriteRune(r)
+				}
+			}
+			return sb.String(), nil
+		}, nil
+	case "bool":
+		return func(v string) (string, error) {
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "", "#n/a":
+				return v, nil
+			case "yes", "y", "1", "true", "t":
+				return "true", nil
+			}
+			return "false", nil
+		}, nil
+	case "truncate":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("truncate needs a length [%v]", arg)
+		}
+		return func(v string) (string, error) {
+			if r := []rune(v); len(r) > n {
+				return string(r[:n]), nil
+			}
+			return v, nil
+		}, nil
+	case "default":
+		return func(v string) (string, error) {
+			if v == "" {
+				return arg, nil
+			}
+			return v, nil
+		}, nil
+	case "prefix", "suffix":
+		return func(v string) (string, error) {
+			if v == "" {
+				return v, nil
+			}
+			if name == "prefix" {
+				return arg + v, nil
+			}
+			return v + arg, nil
+		}, nil
+	case "replace":
+		from, to, ok := strings.Cut(arg, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("replace is written replace:old=new [%v]", arg)
+		}
+		return func(v string) (string, error) { return strings.ReplaceAll(v, from, to), nil }, nil
+	case "date", "datetime":
+		if arg == "" {
+			return nil, fmt.Errorf("%v needs a layout, e.g. %v:02/01/2006", name, name)
+		}
+		out := dateFormat
+		if name == "datetime" {
+			out = dateTimeFormat
+		}
+		return func(v string) (string, error) {
+			if strings.TrimSpace(v) == "" {
+				return v, nil
+			}
+			t, err := time.Parse(arg, strings.TrimSpace(v))
+			if err != nil {
+				return "", fmt.Errorf("[%v] doesn't match %v", v, arg)
+			}
+			return t.UTC().Format(out), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown transform %v", name)
+}
+
+// capitalises the first letter of each word
+func title(v string) string {
+	words := strings.Fields(strings.ToLower(v))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
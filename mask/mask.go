@@ -0,0 +1,260 @@
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+/*
+	Masks personal data so a copy of production can be used in a sandbox.
+
+	Each value is replaced by one built from a keyed HMAC of the original, so with the same key the
+	same email always becomes the same fake email, whichever object or run it turns up in. The fakes
+	keep the shape of what they replace, emails are still emails and phone numbers keep their country
+	code and punctuation.
+*/
+
+// what to do with a field
+const (
+	Mask    = "mask"    // replace with a fake of the same kind
+	Keep    = "keep"    // leave as it is
+	Null    = "null"    // clear it
+	Shuffle = "shuffle" // move the real values between the records
+)
+
+// what the bulk api takes as null
+const NullValue = "#N/A"
+
+// returns the policy if it is one we know
+func ParsePolicy(s string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(s))
+	switch p {
+	case Mask, Keep, Null, Shuffle:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown mask policy %v, use mask, keep, null or shuffle", s)
+}
+
+// the kind of value being masked, values of the same kind mask the same way whichever field they are in
+type Kind string
+
+const (
+	Text      Kind = "text"
+	Email     Kind = "email"
+	Phone     Kind = "phone"
+	Url       Kind = "url"
+	FirstName Kind = "firstname"
+	LastName  Kind = "lastname"
+	FullName  Kind = "fullname"
+	Company   Kind = "company"
+	Street    Kind = "street"
+)
+
+// objects whose Name field is a person's name
+var personObjects = map[string]bool{"contact": true, "lead": true, "user": true, "individual": true}
+
+// returns the kind of value a field holds from its object, name and describe type
+func KindOf(obj string, field string, sfType string) Kind {
+	switch sfType {
+	case "email":
+		return Email
+	case "phone":
+		return Phone
+	case "url":
+		return Url
+	}
+	name := strings.ToLower(field)
+	switch {
+	case name == "firstname" || name == "middlename":
+		return FirstName
+	case name == "lastname":
+		return LastName
+	case name == "suppliedname" || (name == "name" && personObjects[strings.ToLower(obj)]):
+		return FullName
+	case name == "name" && strings.EqualFold(obj, "account"), name == "company", name == "suppliedcompany":
+		return Company
+	case strings.HasSuffix(name, "street"):
+		return Street
+	}
+	return Text
+}
+
+// returns the policy for a field that hasn't been configured. Text that could hold personal data is
+// masked, everything else (and the parts of an address that are picklists in many orgs) is kept.
+func DefaultPolicy(field string, sfType string) string {
+	switch sfType {
+	case "string", "textarea", "email", "phone", "url", "encryptedstring":
+	default:
+		return Keep
+	}
+	name := strings.ToLower(field)
+	for _, s := range []string{"city", "state", "country", "statecode", "countrycode"} {
+		if strings.HasSuffix(name, s) {
+			return Keep
+		}
+	}
+	return Mask
+}
+
+type Masker struct {
+	key []byte
+}
+
+// returns a masker for the key, the key is what makes the fakes impossible to reverse so keep it secret
+func New(key string) (*Masker, error) {
+	if key == "" {
+		return nil, fmt.Errorf("a key is needed to mask data")
+	}
+	return &Masker{key: []byte(key)}, nil
+}
+
+// returns a random source seeded from the HMAC of the value
+func (m *Masker) source(kind Kind, v string) *rand.Rand {
+	h := hmac.New(sha256.New, m.key)
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write([]byte(v))
+	return rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(h.Sum(nil)))))
+}
+
+// returns the fake for the value, blanks stay blank
+func (m *Masker) Value(kind Kind, v string) string {
+	if strings.TrimSpace(v) == "" {
+		return v
+	}
+	// case and surrounding space don't make a value different
+	r := m.source(kind, strings.ToLower(strings.TrimSpace(v)))
+	switch kind {
+	case Email:
+		return email(r)
+	case Phone:
+		return phone(r, v)
+	case Url:
+		return url(r)
+	case FirstName:
+		return pick(r, firstNames)
+	case LastName:
+		return pick(r, lastNames)
+	case FullName:
+		return pick(r, firstNames) + " " + pick(r, lastNames)
+	case Company:
+		return company(r)
+	case Street:
+		return street(r, v)
+	}
+	return text(r, v)
+}
+
+// moves the values between the rows, the same column and values always shuffle the same way
+func (m *Masker) Shuffle(column string, values []string) {
+	r := m.source("shuffle", strings.ToLower(column))
+	r.Shuffle(len(values), func(i, j int) {
+		values[i], values[j] = values[j], values[i]
+	})
+}
+
+func pick(r *rand.Rand, from []string) string {
+	return from[r.Intn(len(from))]
+}
+
+func email(r *rand.Rand) string {
+	return fmt.Sprintf("%v.%v%d@%v", strings.ToLower(pick(r, firstNames)), ascii(pick(r, lastNames)), r.Intn(1000), pick(r, domains))
+}
+
+func url(r *rand.Rand) string {
+	return fmt.Sprintf("https://www.%v%d.%v", ascii(pick(r, lastNames)), r.Intn(100), pick(r, domains))
+}
+
+func company(r *rand.Rand) string {
+	return pick(r, lastNames) + " " + pick(r, companySuffixes)
+}
+
+// a house number, name and suffix on the first line, any other lines are masked as text
+func street(r *rand.Rand, v string) string {
+	lines := strings.Split(v, "\n")
+	lines[0] = fmt.Sprintf("%d %v %v", r.Intn(998)+1, pick(r, lastNames), pick(r, streetSuffixes))
+	for i := 1; i < len(lines); i++ {
+		lines[i] = text(r, lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaces the digits of the number, keeping an international prefix (+44 or 0044) and the punctuation
+func phone(r *rand.Rand, v string) string {
+	keep := 0
+	t := strings.TrimSpace(v)
+	if strings.HasPrefix(t, "+") || strings.HasPrefix(t, "00") {
+		// the country code is the first group of digits
+		start := strings.IndexFunc(v, unicode.IsDigit)
+		if start < 0 {
+			// no number to keep the code of, "+ ext"
+			return text(r, v)
+		}
+		if strings.HasPrefix(t, "00") {
+			start += 2
+		}
+		end := start
+		for end < len(v) && unicode.IsDigit(rune(v[end])) {
+			end++
+		}
+		if end-start > 3 {
+			// written without spaces, 1 and 7 are the only single digit codes
+			end = start + 2
+			if v[start] == '1' || v[start] == '7' {
+				end = start + 1
+			}
+		}
+		keep = end
+	}
+	out := []byte(v)
+	first := true
+	for i := keep; i < len(out); i++ {
+		if out[i] < '0' || out[i] > '9' {
+			continue
+		}
+		if first {
+			// keep a leading 0 (a trunk prefix) but don't introduce one
+			first = false
+			if out[i] != '0' {
+				out[i] = byte('1' + r.Intn(9))
+			}
+			continue
+		}
+		out[i] = byte('0' + r.Intn(10))
+	}
+	return string(out)
+}
+
+// replaces letters with letters and digits with digits, keeping case, spacing and punctuation
+func text(r *rand.Rand, v string) string {
+	var sb strings.Builder
+	for _, c := range v {
+		switch {
+		case unicode.IsUpper(c):
+			sb.WriteRune(rune('A' + r.Intn(26)))
+		case unicode.IsLetter(c):
+			sb.WriteRune(rune('a' + r.Intn(26)))
+		case unicode.IsDigit(c):
+			sb.WriteRune(rune('0' + r.Intn(10)))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// lower case with anything outside a-z dropped, for the parts of emails and urls
+func ascii(s string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToLower(s) {
+		if c >= 'a' && c <= 'z' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
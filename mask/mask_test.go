@@ -0,0 +1,107 @@
+package mask
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	m, err := New("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := New("another secret")
+
+	// the same value gives the same fake whatever its case, a different key a different fake
+	a := m.Value(Email, "Jane.Doe@acme.com")
+	if b := m.Value(Email, " jane.doe@ACME.com"); a != b {
+		t.Errorf("expected the same email got %v and %v", a, b)
+	}
+	if b := other.Value(Email, "jane.doe@acme.com"); a == b {
+		t.Errorf("expected a different email with another key got %v", b)
+	}
+	if !regexp.MustCompile(`^[a-z]+\.[a-z]+\d+@example\.(com|org|net)$`).MatchString(a) {
+		t.Errorf("%v isn't an example email", a)
+	}
+
+	tests := []struct {
+		kind    Kind
+		value   string
+		pattern string
+	}{
+		{Phone, "+44 20 7946 0018", `^\+44 [1-9]\d \d{4} \d{4}$`},
+		{Phone, "(415) 555-0134", `^\([1-9]\d\d\) \d{3}-\d{4}$`},
+		{Phone, "+14155550134", `^\+1[1-9]\d{9}$`},
+		{Phone, "020 7946 0018", `^0\d\d \d{4} \d{4}$`},
+		{Phone, "+ ext", `^\+ [a-z]{3}$`},
+		{FirstName, "Troy", `^\pL+$`},
+		{FullName, "Troy Sellers", `^\pL+ \pL+$`},
+		{Company, "Acme Corp", `^\pL+ \S+( \S+)?$`},
+		{Street, "1 Market St\nSuite 300", `^\d+ \pL+ [A-Za-z]+\n[A-Z][a-z]{4} \d{3}$`},
+		{Url, "http://acme.com", `^https://www\.[a-z]+\d+\.example\.(com|org|net)$`},
+		{Text, "AB-1234 xy", `^[A-Z]{2}-\d{4} [a-z]{2}$`},
+		{Text, "", `^$`},
+	}
+	for _, tc := range tests {
+		got := m.Value(tc.kind, tc.value)
+		if !regexp.MustCompile(tc.pattern).MatchString(got) {
+			t.Errorf("%v %q masked to %q which doesn't match %v", tc.kind, tc.value, got, tc.pattern)
+		}
+		if tc.value != "" && got == tc.value {
+			t.Errorf("%v %q wasn't masked", tc.kind, tc.value)
+		}
+	}
+	if got := m.Value(Phone, "+"); got != "+" {
+		t.Errorf("expected a phone without digits to stay as it is, got %q", got)
+	}
+	if _, err := New(""); err == nil {
+		t.Error("expected an error without a key")
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	m, _ := New("secret")
+	values := []string{"a", "b", "c", "d", "e", "f"}
+	first := append([]string{}, values...)
+	m.Shuffle("Account.Industry", first)
+	second := append([]string{}, values...)
+	m.Shuffle("account.industry", second)
+	if strings.Join(first, "") != strings.Join(second, "") {
+		t.Errorf("expected the same shuffle got %v and %v", first, second)
+	}
+	if strings.Join(first, "") == strings.Join(values, "") {
+		t.Errorf("%v wasn't shuffled", first)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	if p, err := ParsePolicy(" Shuffle"); err != nil || p != Shuffle {
+		t.Errorf("expected shuffle got %v %v", p, err)
+	}
+	if _, err := ParsePolicy("scramble"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+	tests := []struct {
+		obj, field, sfType string
+		kind               Kind
+		policy             string
+	}{
+		{"Contact", "Email", "email", Email, Mask},
+		{"Contact", "Name", "string", FullName, Mask},
+		{"Account", "Name", "string", Company, Mask},
+		{"Lead", "Company", "string", Company, Mask},
+		{"Account", "BillingStreet", "textarea", Street, Mask},
+		{"Account", "BillingCity", "string", Text, Keep},
+		{"Account", "Industry", "picklist", Text, Keep},
+		{"Account", "AnnualRevenue", "currency", Text, Keep},
+	}
+	for _, tc := range tests {
+		if k := KindOf(tc.obj, tc.field, tc.sfType); k != tc.kind {
+			t.Errorf("%v.%v expected kind %v got %v", tc.obj, tc.field, tc.kind, k)
+		}
+		if p := DefaultPolicy(tc.field, tc.sfType); p != tc.policy {
+			t.Errorf("%v.%v expected policy %v got %v", tc.obj, tc.field, tc.policy, p)
+		}
+	}
+}
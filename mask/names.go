@@ -0,0 +1,34 @@
+package mask
+
+// the names and words masked values are built from
+var firstNames = []string{
+	"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda", "David", "Elizabeth",
+	"William", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Charles", "Karen",
+	"Daniel", "Lisa", "Matthew", "Nancy", "Anthony", "Betty", "Mark", "Sandra", "Donald", "Margaret",
+	"Steven", "Ashley", "Andrew", "Kimberly", "Paul", "Emily", "Joshua", "Donna", "Kenneth", "Michelle",
+	"Kevin", "Carol", "Brian", "Amanda", "George", "Melissa", "Timothy", "Deborah", "Ronald", "Stephanie",
+	"Oliver", "Amelia", "Noah", "Isla", "Leo", "Ava", "Arthur", "Mia", "Oscar", "Freya",
+	"Lukas", "Hanna", "Felix", "Lea", "Hugo", "Chloe", "Louis", "Manon", "Mateus", "Julia",
+	"Haruto", "Yui", "Sota", "Aoi", "Rafael", "Beatriz", "Samuel", "Lucia", "Arjun", "Priya",
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez",
+	"Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin",
+	"Lee", "Perez", "Thompson", "White", "Harris", "Sanchez", "Clark", "Ramirez", "Lewis", "Robinson",
+	"Walker", "Young", "Allen", "King", "Wright", "Scott", "Torres", "Nguyen", "Hill", "Flores",
+	"Green", "Adams", "Nelson", "Baker", "Hall", "Rivera", "Campbell", "Mitchell", "Carter", "Roberts",
+	"Evans", "Hughes", "Edwards", "Wood", "Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Becker",
+	"Bernard", "Dubois", "Durand", "Lefebvre", "Moreau", "Silva", "Santos", "Oliveira", "Souza", "Pereira",
+	"Sato", "Suzuki", "Takahashi", "Tanaka", "Watanabe", "Patel", "Singh", "Kowalski", "Novak", "Jensen",
+}
+
+var companySuffixes = []string{
+	"Holdings", "Group", "Partners", "Industries", "Systems", "Solutions", "Logistics", "Labs", "Trading", "Consulting",
+	"& Co", "Ltd", "Inc", "LLC", "GmbH", "Enterprises", "Ventures", "Services", "Networks", "Foods",
+}
+
+var streetSuffixes = []string{"Street", "Road", "Avenue", "Lane", "Drive", "Court", "Place", "Way", "Terrace", "Close"}
+
+// reserved for documentation, mail sent to them goes nowhere
+var domains = []string{"example.com", "example.org", "example.net"}
@@ -57,16 +57,27 @@ func getSchemaForAccount(fields []interface{}, personAccounts bool) []types.IFie
 
 /*
 	function returns a collection of fields that represent either a Company or Person Account.
+	Person accounts take their name from FirstName and LastName, business accounts from Name.
 */
 func handlePersonAccounts(fields []types.IField, personAccounts bool) []types.IField {
 
 	var newFields []types.IField
 	for _, f := range fields {
-		if personAccounts && (strings.Contains(f.GetField().Name, "__pc") || strings.Index(f.GetField().Name, "Person") == 0) {
+		name := f.GetField().Name
+		if personAccounts && name != "Name" {
 			newFields = append(newFields, f)
-		} else if !personAccounts && !(strings.Contains(f.GetField().Name, "__pc") || strings.Index(f.GetField().Name, "Person") == 0) {
+		} else if !personAccounts && !isPersonField(name) {
 			newFields = append(newFields, f)
 		}
 	}
 	return newFields
 }
+
+// fields that only exist on person accounts
+func isPersonField(name string) bool {
+	switch name {
+	case "FirstName", "LastName", "MiddleName", "Salutation", "Suffix":
+		return true
+	}
+	return strings.HasSuffix(name, "__pc") || strings.Index(name, "Person") == 0
+}
@@ -22,7 +22,9 @@ func getSchemaForCase(fields []interface{}, personAccounts bool) []types.IField
 				mf.Min = 0 // we will populate this one ourselves
 				mockFields = append(mockFields, mf)
 			case "Subject":
+				// mockaroo's sentences are lorem ipsum, so it makes a catch phrase and the native generator a sentence from the text model
 				mf := types.NewCatchPhrase(field)
+				mf.Sentence = true
 				mockFields = append(mockFields, mf)
 			case "SuppliedName":
 				mf := types.NewFullName(field)
@@ -40,8 +42,9 @@ func getSchemaForCase(fields []interface{}, personAccounts bool) []types.IField
 				mf.Formula = fmt.Sprintf("this[0,%d]", int(field["length"].(float64)))
 				mockFields = append(mockFields, mf)
 			default:
-				mf := getMockTypeForField(field)
-				mockFields = append(mockFields, mf)
+				if mf := getMockTypeForField(field); mf != nil {
+					mockFields = append(mockFields, mf)
+				}
 			}
 		}
 	}
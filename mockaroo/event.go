@@ -12,7 +12,6 @@ func getSchemaForEvent(fields []interface{}, personAccounts bool) []types.IField
 
 	for _, f := range fields {
 		field := f.(map[string]interface{})
-		/* formula sytax can include Ruby code. These formulas are designed to adjust start and end times around the activity date */
 		if shouldGetData(field) {
 			var mf types.IField
 			switch field["name"].(string) {
@@ -29,17 +28,9 @@ func getSchemaForEvent(fields []interface{}, personAccounts bool) []types.IField
 				// sets about 70% of the records in the past
 				dt.Formula = "if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end"
 				mf = dt
-			case "StartDateTime":
-				dt := types.NewDatetime(field)
-				// if this isn't an all day event, set a random start time on the same day as the activity date
-				dt.Formula = "if field('IsAllDayEvent') == true then '' else (field('ActivityDate') + random(0.1,0.5)).strftime('%Y-%m-%dT%H:%M:%S.%L%z') end"
-				//dt.Formula = strings.ReplaceAll(dt.Formula, "%d", "\\%d")
-				mf = dt
-			case "EndDateTime":
-				dt := types.NewDatetime(field)
-				// if this isn't an all day event, set a random end time some time after the start time
-				dt.Formula = "if field('IsAllDayEvent') == false then (date(field('StartDateTime')) + random(30,240)).strftime('%Y-%m-%dT%H:%M:%S.%L%z') else field('StartDateTime') end"
-				mf = dt
+			case "StartDateTime", "EndDateTime":
+				// these are set relative to the activity date by the temporal constraints for Event
+				mf = types.NewDatetime(field)
 			case "Priority", "Status", "Subject", "CallType", "Type", "OwnerId", "ShowAs", "IsAllDayEvent", "Location":
 				mf = getMockTypeForField(field)
 			default:
@@ -0,0 +1,129 @@
+package mockaroo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/formula"
+	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/rules"
+)
+
+// checks every formula in the schema parses, only names fields in the schema and runs against a
+// sample row, so a mistake is found before mockaroo is called rather than by it
+func LintSchema(schema []types.IField) error {
+	var errs []error
+	names := make(map[string]bool)
+	for _, f := range schema {
+		names[f.GetField().Name] = true
+	}
+	row := sampleRow(schema)
+	for _, f := range schema {
+		field := f.GetField()
+		if field.Formula == "" {
+			continue
+		}
+		fm, err := formula.Parse(field.Formula)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v : %v", field.Name, err))
+			continue
+		}
+		var unknown []string
+		for _, n := range fm.Fields() {
+			if !names[n] {
+				unknown = append(unknown, n)
+			}
+		}
+		if len(unknown) > 0 {
+			errs = append(errs, fmt.Errorf("%v : the formula names %v, which isn't in the schema", field.Name, strings.Join(unknown, ", ")))
+			continue
+		}
+		if _, err := fm.Eval(formula.Env{This: row[field.Name], Fields: row}); err != nil {
+			errs = append(errs, fmt.Errorf("%v : %v", field.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// a value for each field as a formula would see it
+func sampleRow(schema []types.IField) map[string]interface{} {
+	row := make(map[string]interface{})
+	for _, f := range schema {
+		v := "sample"
+		if g, ok := f.(*types.Generic); ok {
+			if r, err := g.Resolve(); err == nil {
+				v = r.Generate()
+			}
+		} else {
+			v = f.Generate()
+		}
+		row[f.GetField().Name] = formula.Value(v, sforceType(f))
+	}
+	return row
+}
+
+// the salesforce type of the field, a Datetime in a saved schema is taken as a datetime
+func sforceType(f types.IField) string {
+	t, _ := f.GetField().SforceMeta["type"].(string)
+	if t != "date" && f.GetField().FieldType == "Datetime" {
+		return "datetime"
+	}
+	return t
+}
+
+// builds the schemas create would send for the object, both of them for person accounts, and lints them
+func LintObject(obj *simpleforce.SObjectMeta, personAccounts bool, rs rules.Rules) error {
+	schema, err := getSchemaForObjectType(obj, false, rs)
+	if err != nil {
+		return err
+	}
+	if err := LintSchema(schema); err != nil {
+		return err
+	}
+	if personAccounts && strings.EqualFold((*obj)["name"].(string), "account") {
+		schema, err := getSchemaForObjectType(obj, true, rs)
+		if err != nil {
+			return err
+		}
+		if err := LintSchema(schema); err != nil {
+			return fmt.Errorf("person accounts : %v", err)
+		}
+	}
+	return nil
+}
+
+// lints a schema saved as mockaroo json, an array of fields with their name, type and settings
+func LintFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fields []map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return fmt.Errorf("%v isn't a mockaroo schema : %v", path, err)
+	}
+	var schema []types.IField
+	for i, f := range fields {
+		name, _ := f["name"].(string)
+		mockType, _ := f["type"].(string)
+		if name == "" || mockType == "" {
+			return fmt.Errorf("field %d of %v needs a name and a type", i+1, path)
+		}
+		params := make(map[string]interface{})
+		for k, v := range f {
+			switch k {
+			case "name", "type", "formula", "percentBlank":
+			default:
+				params[k] = v
+			}
+		}
+		g := types.NewGeneric(f, mockType, params)
+		g.Formula, _ = f["formula"].(string)
+		schema = append(schema, g)
+	}
+	return LintSchema(schema)
+}
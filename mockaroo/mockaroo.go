@@ -17,7 +17,12 @@ import (
 
 	"github.com/simpleforce/simpleforce"
 	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
 	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/rules"
+	"github.com/troysellers/go-modifier/sforce"
+	"github.com/troysellers/go-modifier/staging"
+	"github.com/troysellers/go-modifier/temporal"
 )
 
 //Output formats
@@ -28,63 +33,208 @@ const CustomFormat string = "generate.custom"
 const SQLFormat string = "generate.sql"
 const XMLFormat string = "generate.xml"
 
+// where the mockaroo api is, tests point it at a local server
+var apiUrl = "https://api.mockaroo.com/api"
+
 type MockarooRequest struct {
-	SObject        *simpleforce.SObjectMeta
-	Cfg            *config.Config
-	Count          int
-	PersonAccounts bool
-	Schema         []types.IField
-	FilePath       string
+	SObject  *simpleforce.SObjectMeta
+	Cfg      *config.Config
+	Count    int
+	Schema   []types.IField
+	FilePath string
+	// share of Account records (0 to 1) that should be person accounts
+	PersonAccountRatio float64
+	// record type given to the generated person accounts
+	PersonRecordTypeId string
+	// the name of a schema saved in mockaroo to fetch instead of the one built from describe
+	SavedSchema string
+	// with a SavedSchema, also generate the fields of the object the saved schema doesn't have
+	MergeLocal bool
 }
 
 // fetches mockaroo CSV for the object specified.
 // returns a string that is the full path
+//
+// Once fetched the date constraints for the object are applied, rows that can't satisfy them are dropped.
 func (r *MockarooRequest) GetDataForObj() error {
+	if err := r.fetchAll(); err != nil {
+		return err
+	}
+	name := (*r.SObject)["name"].(string)
+	constraints, err := temporal.ForObject(name, r.Cfg.Dates)
+	if err != nil {
+		return err
+	}
+	rejected, err := temporal.ApplyToFile(r.FilePath, constraints, temporal.FieldTypes((*r.SObject)["fields"].([]interface{})))
+	if err != nil {
+		return err
+	}
+	if rejected > 0 {
+		log.Printf("Rejected %d %v rows that didn't satisfy the date constraints", rejected, name)
+	}
+	// the data as it goes on to be related and uploaded, in the same row order
+	staging.RecordFile(r.Cfg, staging.Generated, name, name, r.FilePath)
+	return nil
+}
+
+// Accounts with a PersonAccountRatio are fetched as two sets, business and person accounts,
+// each with their own schema. These are merged into one file with the union of the columns.
+func (r *MockarooRequest) fetchAll() error {
 
-	r.Schema = getSchemaForObjectType(r.SObject, r.PersonAccounts)
+	name := (*r.SObject)["name"].(string)
+	if !strings.EqualFold(name, "account") || r.PersonAccountRatio <= 0 {
+		schema, path, err := r.fetch(name, r.Count, false)
+		if err != nil {
+			return err
+		}
+		r.Schema = schema
+		r.FilePath = path
+		return nil
+	}
 
-	b, err := json.Marshal(r.Schema)
+	personCount := int(math.Round(float64(r.Count) * r.PersonAccountRatio))
+	if personCount > r.Count {
+		personCount = r.Count
+	}
+	var files []string
+	if r.Count-personCount > 0 {
+		schema, path, err := r.fetch(fmt.Sprintf("%v-business", name), r.Count-personCount, false)
+		if err != nil {
+			return err
+		}
+		r.Schema = append(r.Schema, schema...)
+		files = append(files, path)
+	}
+	if personCount > 0 {
+		schema, path, err := r.fetch(fmt.Sprintf("%v-person", name), personCount, true)
+		if err != nil {
+			return err
+		}
+		if r.PersonRecordTypeId != "" {
+			rts := make([]string, personCount)
+			for i := range rts {
+				rts[i] = r.PersonRecordTypeId
+			}
+			if err := file.SetColumn(path, "RecordTypeId", rts); err != nil {
+				return err
+			}
+		}
+		for _, f := range schema {
+			if !hasField(r.Schema, f.GetField().Name) {
+				r.Schema = append(r.Schema, f)
+			}
+		}
+		files = append(files, path)
+	}
+	var err error
+	r.FilePath, err = file.UnionCsv(fmt.Sprintf("%v%v.csv", r.Cfg.Mockaroo.DataDir, name), files...)
+	return err
+}
+
+func hasField(schema []types.IField, name string) bool {
+	for _, f := range schema {
+		if f.GetField().Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fetches count records in batches and merges them into name.csv
+func (r *MockarooRequest) fetch(name string, count int, personAccounts bool) ([]types.IField, string, error) {
+
+	if r.SavedSchema != "" {
+		return r.fetchSaved(name, count, personAccounts)
+	}
+	schema, path, err := r.generate(name, count, personAccounts)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
+	staging.RecordFile(r.Cfg, staging.Generated, (*r.SObject)["name"].(string), name, path)
+	return schema, path, nil
+}
+
+// makes count records from the schema built from describe, locally or by mockaroo, into name.csv
+func (r *MockarooRequest) generate(name string, count int, personAccounts bool) ([]types.IField, string, error) {
+
+	rs, err := rules.Get(r.Cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	schema, err := getSchemaForObjectType(r.SObject, personAccounts, rs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// formulas are checked here, mockaroo would only report them once it had been called
+	if err := LintSchema(schema); err != nil {
+		return nil, "", fmt.Errorf("the %v schema has formula errors\n%v", name, err)
+	}
+
+	if r.Cfg.Mockaroo.Native() {
+		path, err := generateNative(schema, count, fmt.Sprintf("%v%v.csv", r.Cfg.Mockaroo.DataDir, name))
+		if err != nil {
+			return nil, "", err
+		}
+		return schema, path, nil
+	}
+
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, "", err
+	}
+	path, err := r.fetchBatches(name, count, "", b)
+	if err != nil {
+		return nil, "", err
+	}
+	return schema, path, nil
+}
+
+// fetches count records from mockaroo in batches and merges them into name.csv. The query is added
+// to the url of each batch, the body is the schema (nil for a saved one)
+func (r *MockarooRequest) fetchBatches(name string, count int, query string, body []byte) (string, error) {
 
-	header := true
 	// mockaroo has a 5000 record api limit.
 	mockLimit := 250
 	var wg sync.WaitGroup
 	var files sync.Map
-	var index int
 
-	numBatches := r.Count / mockLimit
+	sizes := batchSizes(count, mockLimit)
+	// a failed batch is reported once they have all finished
+	errs := make(chan error, len(sizes))
 
-	for i := 1; i <= numBatches; i++ {
-		log.Printf("fetching %d to %d dummy data\n", (i-1)*mockLimit, i*mockLimit)
+	for i, size := range sizes {
+		// batches are merged in the order of their key, the first has the header
+		key := i + 1
+		log.Printf("fetching %d to %d dummy data\n", i*mockLimit, i*mockLimit+size)
 		wg.Add(1)
-		fname := fmt.Sprintf("%v%v-%d.csv", r.Cfg.Mockaroo.DataDir, (*r.SObject)["name"].(string), i)
-		go fetchMockarooBatch(fname, r.Cfg.Mockaroo.Key, mockLimit, b, header, &wg, &files, i)
-
-		if header {
-			header = false
-		}
-		index = i
-		if math.Mod(float64(i), 4) == 0 {
+		fname := fmt.Sprintf("%v%v-%d.csv", r.Cfg.Mockaroo.DataDir, name, key)
+		url := fmt.Sprintf("%v/generate.csv?key=%v&count=%d&include_header=%v%v", apiUrl, r.Cfg.Mockaroo.Key, size, key == 1, query)
+		go fetchMockarooBatch(fname, url, body, &wg, &files, key, errs)
+		if math.Mod(float64(key), 4) == 0 {
 			wg.Wait()
 		}
 	}
-	// mod gives us the remaining records to get.
-	remainder := int(math.Mod(float64(r.Count), float64(mockLimit)))
-	if remainder > 0 {
-		fname := fmt.Sprintf("%v%v-%d.csv", r.Cfg.Mockaroo.DataDir, (*r.SObject)["name"].(string), index)
-		wg.Add(1)
-		go fetchMockarooBatch(fname, r.Cfg.Mockaroo.Key, remainder, b, header, &wg, &files, index)
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return "", err
 	}
+	return mergeFiles(&files, r.Cfg.Mockaroo.DataDir, name)
+}
 
-	wg.Wait()
-	r.FilePath, err = mergeFiles(&files, r.Cfg.Mockaroo.DataDir, (*r.SObject)["name"].(string))
-	if err != nil {
-		return err
+// the number of records in each batch, full batches of limit then whatever remains
+func batchSizes(count int, limit int) []int {
+	var sizes []int
+	for count > 0 {
+		n := limit
+		if count < limit {
+			n = count
+		}
+		sizes = append(sizes, n)
+		count -= n
 	}
-	return nil
+	return sizes
 }
 
 func mergeFiles(files *sync.Map, dir string, obj string) (string, error) {
@@ -121,36 +271,39 @@ func mergeFiles(files *sync.Map, dir string, obj string) (string, error) {
 func setFormula(f *types.Field) {
 	l := int(f.SforceMeta["length"].(float64))
 	if l > 0 {
-		if l > 1000 { //lets not go crazy with text
-			l = 1000
-		}
-		f.Formula = fmt.Sprintf("if this.nil? then '' else this[0,%d] end", l)
+		f.Formula = lengthFormula(l)
 	}
 }
 
-func fetchMockarooBatch(fname string, key string, records int, schema []byte, header bool, wg *sync.WaitGroup, files *sync.Map, mapkey int) {
+// fetches one batch from the url, posting the schema if there is one
+func fetchMockarooBatch(fname string, url string, schema []byte, wg *sync.WaitGroup, files *sync.Map, mapkey int, errs chan<- error) {
 
 	//log.Printf("Fetching mockaroo schema \n%v\n", string(schema))
 	defer wg.Done()
 	f, err := os.Create(fname)
 	if err != nil {
-		log.Printf("%v", err)
+		errs <- err
+		return
 	}
 	defer f.Close()
 
 	headers := make(map[string]string)
 	headers["Accept"] = "application/json"
-	headers["Content-Type"] = "application/json"
-	url := fmt.Sprintf("https://api.mockaroo.com/api/generate.csv?key=%v&count=%d&include_header=%v", key, records, header)
+	method := "GET"
+	if schema != nil {
+		headers["Content-Type"] = "application/json"
+		method = "POST"
+	}
 
-	_, respbytes, err := doHttp(url, "", schema, "POST", headers)
+	_, respbytes, err := doHttp(url, "", schema, method, headers)
 	if err != nil {
-		log.Printf("%v", err)
-		panic(err)
+		errs <- fmt.Errorf("mockaroo : %v", err)
+		return
 	}
 
 	if _, err := f.Write(respbytes); err != nil {
-		panic(err)
+		errs <- err
+		return
 	}
 	files.Store(mapkey, f.Name())
 }
@@ -184,7 +337,11 @@ func getMockTypeForField(f map[string]interface{}) types.IField {
 		mockType = types.NewBoolean(f)
 	case "string", "encryptedstring":
 		if f["externalId"].(bool) || f["unique"].(bool) {
-			mockType = types.NewGUID(f)
+			// left blank, unique values are filled from a persisted sequence once the data is fetched
+			w := types.NewWords(f)
+			w.Max = 0
+			w.Min = 0
+			mockType = w
 		} else {
 			mockType = types.NewWords(f)
 		}
@@ -231,13 +388,23 @@ func getMockTypeForField(f map[string]interface{}) types.IField {
 
 // returns a mocktype that is ideal for the object
 // or defaults for custom object
-func getSchemaForObjectType(obj *simpleforce.SObjectMeta, personAccounts bool) []types.IField {
+// Every required field is given a generator, it is an error if there isn't a sensible one.
+// The generation rules for the object are applied last.
+func getSchemaForObjectType(obj *simpleforce.SObjectMeta, personAccounts bool, rs rules.Rules) ([]types.IField, error) {
 
 	var schema []types.IField
 	var fields = (*obj)["fields"].([]interface{})
+	// fields that are deliberately left out of this schema even when required
+	excluded := func(name string) bool { return false }
 	switch (*obj)["name"].(string) {
 	case "Account", "account":
 		schema = getSchemaForAccount(fields, personAccounts)
+		excluded = func(name string) bool {
+			if personAccounts {
+				return name == "Name"
+			}
+			return isPersonField(name)
+		}
 	case "Contact", "contact":
 		schema = getSchemaForContact(fields, personAccounts)
 	case "Case", "case":
@@ -253,10 +420,42 @@ func getSchemaForObjectType(obj *simpleforce.SObjectMeta, personAccounts bool) [
 	default:
 		schema = getSchemaForGenericObj(fields)
 	}
+	schema, err := ensureRequired(schema, fields, excluded)
+	if err != nil {
+		return nil, err
+	}
 	for _, f := range schema {
 		setFormula(f.GetField())
 	}
-	return schema
+	return applyRules(schema, (*obj)["name"].(string), fields, rs), nil
+}
+
+// adds a generator for every required field (createable, not nillable, no default) missing from the schema.
+// Reference fields get an empty column that is populated from records in the org.
+// Returns an error listing the required fields we have no sensible generator for.
+func ensureRequired(schema []types.IField, fields []interface{}, excluded func(string) bool) ([]types.IField, error) {
+	var missing []string
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		name := field["name"].(string)
+		if !sforce.IsRequired(field) || hasField(schema, name) || excluded(name) {
+			continue
+		}
+		mf := getMockTypeForField(field)
+		if l, ok := mf.(*types.CustomList); ok && len(l.Values) == 0 {
+			mf = nil
+		}
+		if mf == nil {
+			missing = append(missing, fmt.Sprintf("%v (%v)", name, field["type"]))
+			continue
+		}
+		log.Printf("Adding required field %v\n", name)
+		schema = append(schema, mf)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no generator for the required fields %v", strings.Join(missing, ", "))
+	}
+	return schema, nil
 }
 
 // returns the mockaroo schema for any object we haven't
@@ -268,13 +467,10 @@ func getSchemaForGenericObj(fields []interface{}) []types.IField {
 		field := f.(map[string]interface{})
 		if shouldGetData(field) {
 			mf := getMockTypeForField(field)
-			l := int(field["length"].(float64))
-			if l > 0 {
-				if l > 1000 { //lets not go crazy with text
-					l = 1000
-				}
-				mf.SetFormula(fmt.Sprintf("this.nil? then '' else this[0,%d] end", l))
+			if mf == nil {
+				continue
 			}
+			setFormula(mf.GetField())
 			mockFields = append(mockFields, mf)
 		}
 	}
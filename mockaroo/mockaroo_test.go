@@ -1,13 +1,26 @@
 package mockaroo
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/lorem"
 	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/rules"
 )
 
 func TestNothing(t *testing.T) {
@@ -49,3 +62,374 @@ func TestNothing(t *testing.T) {
 	log.Printf("written to %v\n", filePath)
 
 }
+
+// the describe metadata of a field, extra overrides the defaults
+func testField(name string, typ string, length float64, extra map[string]interface{}) map[string]interface{} {
+	f := map[string]interface{}{
+		"name": name, "type": typ, "length": length, "createable": true, "updateable": true, "nillable": true,
+		"defaultedOnCreate": false, "externalId": false, "unique": false, "precision": float64(0), "scale": float64(0),
+		"picklistValues": []interface{}{},
+	}
+	for k, v := range extra {
+		f[k] = v
+	}
+	return f
+}
+
+func TestHandlePersonAccounts(t *testing.T) {
+	var fields []types.IField
+	for _, n := range []string{"Name", "FirstName", "LastName", "PersonEmail", "Loyalty__pc", "Phone"} {
+		fields = append(fields, types.NewWords(map[string]interface{}{"name": n}))
+	}
+	names := func(fs []types.IField) string {
+		var s string
+		for _, f := range fs {
+			s += f.GetField().Name + " "
+		}
+		return s
+	}
+	if got := names(handlePersonAccounts(fields, false)); got != "Name Phone " {
+		t.Errorf("business account fields [%v]", got)
+	}
+	if got := names(handlePersonAccounts(fields, true)); got != "FirstName LastName PersonEmail Loyalty__pc Phone " {
+		t.Errorf("person account fields [%v]", got)
+	}
+}
+
+func TestEnsureRequired(t *testing.T) {
+	fields := []interface{}{
+		testField("Name", "string", 0, map[string]interface{}{"nillable": false}),
+		testField("Parent__c", "reference", 0, map[string]interface{}{"nillable": false}),
+		testField("Notes__c", "textarea", 0, nil),
+	}
+	schema, err := ensureRequired(nil, fields, func(string) bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) != 2 || !hasField(schema, "Name") || !hasField(schema, "Parent__c") {
+		t.Errorf("required fields were not added %v", schema)
+	}
+
+	fields = append(fields, testField("Where__c", "location", 0, map[string]interface{}{"nillable": false}), testField("Stage__c", "picklist", 0, map[string]interface{}{"nillable": false}))
+	_, err = ensureRequired(nil, fields, func(string) bool { return false })
+	if err == nil || !strings.Contains(err.Error(), "Where__c (location)") || !strings.Contains(err.Error(), "Stage__c (picklist)") {
+		t.Errorf("expected the fields without a generator to be listed, got %v", err)
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	fields := []interface{}{testField("Name", "string", 40, map[string]interface{}{"nillable": false}), testField("Phone", "phone", 40, nil), testField("Description", "textarea", 40, nil), testField("Rating", "picklist", 40, nil)}
+	var schema []types.IField
+	for _, f := range fields[:3] {
+		schema = append(schema, types.NewWords(f.(map[string]interface{})))
+	}
+	hot := "Hot"
+	rs := rules.Rules{
+		"account": {
+			"Phone":       {Generator: "Phone", Params: map[string]interface{}{"format": "(###) ###-####"}, PercentBlank: 20},
+			"Description": {Skip: true},
+			"Rating":      {Value: &hot},
+			"Name":        {Skip: true},
+		},
+	}
+	schema = applyRules(schema, "Account", fields, rs)
+	b, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"name":"Name","percentBlank":0,"formula":"","type":"Words","max":5,"min":1},` +
+		`{"format":"(###) ###-####","formula":"if this.nil? then '' else this[0,40] end","name":"Phone","percentBlank":20,"type":"Phone"},` +
+		`{"name":"Rating","percentBlank":0,"formula":"if this.nil? then '' else this[0,40] end","type":"Custom List","distribution":"","selectionStyle":"random","values":["Hot"]}]`
+	if string(b) != want {
+		t.Errorf("expected\n%v\ngot\n%v", want, string(b))
+	}
+}
+
+func TestGenerateNative(t *testing.T) {
+	schema := []types.IField{
+		types.NewFirstName(testField("FirstName", "string", 40, nil)),
+		types.NewWords(testField("Title", "string", 5, nil)),
+		types.NewGeneric(testField("Phone", "string", 40, nil), "Phone", map[string]interface{}{"format": "(###) ###-####"}),
+	}
+	blank := types.NewEmailAddress(testField("Email", "string", 80, nil))
+	blank.PercentBlank = 100
+	schema = append(schema, blank)
+	for _, f := range schema {
+		setFormula(f.GetField())
+	}
+
+	path, err := generateNative(schema, 20, fmt.Sprintf("%v/native.csv", t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 21 {
+		t.Fatalf("expected a header and 20 rows, got %d lines", len(data))
+	}
+	if strings.Join(data[0], ",") != "FirstName,Title,Phone,Email" {
+		t.Errorf("unexpected header %v", data[0])
+	}
+	phone := regexp.MustCompile(`^\(\d{3}\) \d{3}-\d{4}$`)
+	for _, row := range data[1:] {
+		if row[0] == "" {
+			t.Errorf("expected a first name in %v", row)
+		}
+		if len([]rune(row[1])) > 5 {
+			t.Errorf("expected %v cut to the length of the field", row[1])
+		}
+		if !phone.MatchString(row[2]) {
+			t.Errorf("expected the phone format from the params, got %v", row[2])
+		}
+		if row[3] != "" {
+			t.Errorf("expected the email to always be blank, got %v", row[3])
+		}
+	}
+
+	unknown := []types.IField{types.NewGeneric(testField("Thing", "string", 40, nil), "Movie Title", nil)}
+	if _, err := generateNative(unknown, 1, fmt.Sprintf("%v/unknown.csv", t.TempDir())); err == nil {
+		t.Error("expected an error for a type without a local generator")
+	}
+}
+
+func TestLintSchema(t *testing.T) {
+	activity := types.NewDatetime(testField("ActivityDate", "date", 0, nil))
+	activity.Formula = "if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end"
+	subject := types.NewWords(testField("Subject", "string", 0, nil))
+	subject.Formula = "if this.nil? then '' else this[0,40] end"
+	due := types.NewWords(testField("Due__c", "string", 0, nil))
+	due.Formula = "field('ActivityDate').strftime('%d/%m/%Y')"
+	if err := LintSchema([]types.IField{activity, subject, due}); err != nil {
+		t.Errorf("expected the schema to lint, got %v", err)
+	}
+
+	// the formula the generic schema used to send
+	subject.Formula = "this.nil? then '' else this[0,40] end"
+	due.Formula = "field('Missing__c') + this"
+	activity.Formula = "this[0,10]"
+	err := LintSchema([]types.IField{activity, subject, due})
+	if err == nil {
+		t.Fatal("expected the formulas to fail")
+	}
+	for _, want := range []string{"Subject : unexpected \"then\"", "Due__c : the formula names Missing__c", "ActivityDate : undefined method [] for Date"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in\n%v", want, err)
+		}
+	}
+
+	path := fmt.Sprintf("%v/schema.json", t.TempDir())
+	schema := `[{"name":"Name","type":"Words","min":1,"max":3,"formula":"this.upcase"},` +
+		`{"name":"Close","type":"Datetime","min":"01/01/2024","max":"12/31/2024","formula":"(this + 30).strftime('%Y')"},` +
+		`{"name":"Bad","type":"Movie Title","formula":"if this then"}]`
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = LintFile(path)
+	if err == nil || strings.Count(err.Error(), "\n") != 0 || !strings.HasPrefix(err.Error(), "Bad :") {
+		t.Errorf("expected only the Bad formula to fail, got %v", err)
+	}
+}
+
+func TestGenerateNativeFormulas(t *testing.T) {
+	activity := types.NewDatetime(testField("ActivityDate", "date", 0, nil))
+	activity.Formula = "if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end"
+	due := types.NewWords(testField("Due__c", "string", 10, nil))
+	due.Formula = "(field('ActivityDate') + 1).strftime('%d/%m/%Y')"
+	g := types.NewGeneric(testField("Code__c", "string", 20, nil), "Digit Sequence", map[string]interface{}{"format": "###"})
+	g.Formula = "'C-' + this"
+	path, err := generateNative([]types.IField{activity, due, g}, 50, fmt.Sprintf("%v/formulas.csv", t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	code := regexp.MustCompile(`^C-\d{3}$`)
+	for _, row := range data[1:] {
+		d, err := time.Parse("2006-01-02", row[0])
+		if err != nil {
+			t.Fatalf("expected a date, got %v", row[0])
+		}
+		if d.Before(today.AddDate(-1, 0, -1)) || d.After(today.AddDate(1, 0, 1)) {
+			t.Errorf("%v is more than a year from today", row[0])
+		}
+		if row[1] != d.AddDate(0, 0, 1).Format("02/01/2006") {
+			t.Errorf("expected the day after %v, got %v", row[0], row[1])
+		}
+		if !code.MatchString(row[2]) {
+			t.Errorf("expected a C- code, got %v", row[2])
+		}
+	}
+}
+
+func TestBatchSizes(t *testing.T) {
+	tests := []struct {
+		count int
+		want  string
+	}{
+		{10, "[10]"},
+		{250, "[250]"},
+		{251, "[250 1]"},
+		{1100, "[250 250 250 250 100]"},
+		{0, "[]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprintf("%v", batchSizes(test.count, 250)); got != test.want {
+			t.Errorf("%d : expected %v got %v", test.count, test.want, got)
+		}
+	}
+}
+
+func TestGenerateNativeSeeded(t *testing.T) {
+	schema := func() []types.IField {
+		activity := types.NewDatetime(testField("ActivityDate", "date", 0, nil))
+		activity.Formula = "if random(0,10) <= 7 then Date.today - random(0,365) else Date.today + random(0,365) end"
+		rating := types.NewCustomList(testField("Rating", "picklist", 40, nil))
+		rating.Values = []string{"Hot", "Warm", "Cold"}
+		return []types.IField{
+			types.NewFullName(testField("Name", "string", 80, nil)),
+			types.NewSentences(testField("Description", "textarea", 255, nil)),
+			types.NewEmailAddress(testField("Email", "email", 80, nil)),
+			types.NewGUID(testField("Key__c", "string", 36, nil)),
+			rating,
+			activity,
+		}
+	}
+	dir := t.TempDir()
+	generate := func(seed int64, name string) string {
+		random.SetSeed(seed)
+		path, err := generateNative(schema(), 100, fmt.Sprintf("%v/%v.csv", dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	first, second, other := generate(7, "first"), generate(7, "second"), generate(8, "other")
+	if first != second {
+		t.Error("expected the same seed to make the same file")
+	}
+	if first == other {
+		t.Error("expected another seed to make a different file")
+	}
+}
+
+func TestFetchSaved(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.URL.Path == "/datasets/regions" {
+			if r.Header.Get("Content-Type") != "text/csv" {
+				t.Errorf("expected a text/csv upload, got %v", r.Header.Get("Content-Type"))
+			}
+			return
+		}
+		if r.URL.Query().Get("include_header") == "true" {
+			fmt.Fprint(w, "lastname,Industry,Internal Note\n")
+		}
+		n, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(w, "Saved%d,Banking,drop me\n", i)
+		}
+	}))
+	defer server.Close()
+	defer func(u string) { apiUrl = u }(apiUrl)
+	apiUrl = server.URL
+
+	obj := simpleforce.SObjectMeta{"name": "Lead", "fields": []interface{}{testField("LastName", "string", 40, nil), testField("Industry", "string", 40, nil), testField("FirstName", "string", 40, nil), testField("Phone", "phone", 40, nil)}}
+	r := &MockarooRequest{
+		SObject:     &obj,
+		Cfg:         &config.Config{Mockaroo: config.MockarooConfig{Key: "k", DataDir: t.TempDir() + "/", Backend: "native"}},
+		Count:       300,
+		SavedSchema: "Curated Leads",
+		MergeLocal:  true,
+	}
+	if err := r.GetDataForObj(); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "schema=Curated+Leads") || !strings.HasPrefix(queries[0], "GET ") {
+		t.Errorf("expected two GETs of the saved schema, got %v", queries)
+	}
+	data, err := file.ReadCsv(r.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 301 {
+		t.Fatalf("expected a header and 300 rows, got %d", len(data))
+	}
+	header := strings.Join(data[0], ",")
+	if !strings.HasPrefix(header, "LastName,Industry,") || strings.Contains(header, "Internal Note") || strings.Count(header, "LastName") != 1 {
+		t.Errorf("unexpected header %v", header)
+	}
+	if data[1][0] != "Saved0" || data[1][1] != "Banking" {
+		t.Errorf("expected the saved values to win, got %v", data[1])
+	}
+	for _, name := range []string{"LastName", "Industry", "FirstName"} {
+		if !hasField(r.Schema, name) {
+			t.Errorf("expected %v in the schema", name)
+		}
+	}
+
+	queries = nil
+	path := t.TempDir() + "/regions.csv"
+	os.WriteFile(path, []byte("region\nEMEA\n"), 0644)
+	if err := UploadDataset("k", "", path); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || queries[0] != "POST /datasets/regions?key=k" {
+		t.Errorf("unexpected upload %v", queries)
+	}
+
+	if _, _, err := r.fetchSaved("Account-person", 10, true); err == nil || !strings.Contains(err.Error(), "person accounts") {
+		t.Errorf("expected person accounts to be refused, got %v", err)
+	}
+}
+
+func TestCaseSubject(t *testing.T) {
+	subject := map[string]interface{}{"name": "Subject", "type": "string", "length": float64(255), "updateable": true}
+	schema := getSchemaForCase([]interface{}{subject}, false)
+	if len(schema) != 1 {
+		t.Fatalf("expected the subject in the schema, got %d fields", len(schema))
+	}
+	b, _ := json.Marshal(schema[0])
+	if !strings.Contains(string(b), `"type":"Catch Phrase"`) {
+		t.Errorf("mockaroo should be sent a catch phrase, got %s", b)
+	}
+	random.SetSeed(1)
+	if s := schema[0].Generate(); !strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "?") && !strings.HasSuffix(s, "!") {
+		t.Errorf("expected a sentence of the text model, got %q", s)
+	}
+}
+
+func TestPhoneFormat(t *testing.T) {
+	gb, err := lorem.GetLocale("en_GB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := types.NewPhone(testField("Phone", "phone", 40, nil))
+	national := regexp.MustCompile(`^0\d{2,4} \d{3,6}( \d{4})?$`)
+	if got := p.GenerateFor(gb); !national.MatchString(got) {
+		t.Errorf("expected a number of the locale, got %v", got)
+	}
+	p.Format = "(###) ###-####"
+	if got := p.GenerateFor(gb); !regexp.MustCompile(`^\(\d{3}\) \d{3}-\d{4}$`).MatchString(got) {
+		t.Errorf("expected the format of the rule, got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package mockaroo
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/formula"
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/random"
+)
+
+// writes count records made from the schema without calling mockaroo, in the layout mockaroo
+// returns them: a header of the field names then a row for each record.
+// Each record takes its names and addresses from one of the run's locales.
+// Formulas are applied once every field in the row has a value, in the order of the schema.
+func generateNative(schema []types.IField, count int, path string) (string, error) {
+	fields := make([]types.IField, len(schema))
+	formulas := make([]*formula.Formula, len(schema))
+	header := make([]string, len(schema))
+	for i, f := range schema {
+		if g, ok := f.(*types.Generic); ok {
+			resolved, err := g.Resolve()
+			if err != nil {
+				return "", err
+			}
+			f = resolved
+		}
+		fields[i] = f
+		header[i] = f.GetField().Name
+		if fm := f.GetField().Formula; fm != "" {
+			parsed, err := formula.Parse(fm)
+			if err != nil {
+				return "", fmt.Errorf("%v : %v", header[i], err)
+			}
+			formulas[i] = parsed
+		}
+	}
+	data := [][]string{header}
+	now := random.Now()
+	for r := 0; r < count; r++ {
+		row := make([]string, len(fields))
+		values := make(map[string]interface{})
+		// one locale for the record, so its name, address and company go together
+		loc := lorem.PickLocale().Record()
+		for i, f := range fields {
+			if l, ok := f.(types.Localised); ok {
+				row[i] = l.GenerateFor(loc)
+			} else {
+				row[i] = f.Generate()
+			}
+			values[header[i]] = formula.Value(row[i], sforceType(f))
+		}
+		for i := range fields {
+			if formulas[i] != nil {
+				v, err := formulas[i].Eval(formula.Env{This: values[header[i]], Fields: values, Now: now})
+				if err != nil {
+					return "", fmt.Errorf("%v row %d : %v", header[i], r+1, err)
+				}
+				values[header[i]] = v
+				row[i] = formula.String(v)
+			}
+		}
+		for i, f := range fields {
+			if pb := f.GetField().PercentBlank; pb > 0 && random.Intn(100) < pb {
+				row[i] = ""
+				continue
+			}
+			row[i] = truncate(row[i], fieldLength(f))
+		}
+		data = append(data, row)
+	}
+	log.Printf("Generated %d records locally", count)
+	return file.WriteCsv(path, data)
+}
+
+// the formula that cuts mockaroo values to the length of the field
+func lengthFormula(l int) string {
+	if l > 1000 { //lets not go crazy with text
+		l = 1000
+	}
+	return fmt.Sprintf("if this.nil? then '' else this[0,%d] end", l)
+}
+
+func fieldLength(f types.IField) int {
+	l, _ := f.GetField().SforceMeta["length"].(float64)
+	return int(l)
+}
+
+// what the length formula does to a value
+func truncate(v string, l int) string {
+	if l > 1000 {
+		l = 1000
+	}
+	if r := []rune(v); l > 0 && len(r) > l {
+		return string(r[:l])
+	}
+	return v
+}
@@ -3,9 +3,9 @@ package mockaroo
 import (
 	"log"
 	"strings"
-	"time"
 
 	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/random"
 )
 
 func getSchemaForOpportunity(fields []interface{}, personAccounts bool) []types.IField {
@@ -33,8 +33,8 @@ func getSchemaForOpportunity(fields []interface{}, personAccounts bool) []types.
 				mf = list
 			case "CloseDate":
 				dt := types.NewDatetime(field)
-				dt.Min = time.Now().Format("01/02/2006")
-				dt.Max = time.Now().AddDate(1, 6, 0).Format("01/02/2006")
+				dt.Min = random.Now().Format("01/02/2006")
+				dt.Max = random.Now().AddDate(1, 6, 0).Format("01/02/2006")
 				mf = dt
 			default:
 				mf = getMockTypeForField(field)
@@ -0,0 +1,82 @@
+package mockaroo
+
+import (
+	"log"
+
+	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/rules"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+// changes the schema to follow the rules for the object, fields can be dropped, given another
+// generator, a fixed value or list of values, or left blank some of the time
+func applyRules(schema []types.IField, obj string, fields []interface{}, rs rules.Rules) []types.IField {
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		name := field["name"].(string)
+		rule, ok := rs.For(obj, name)
+		if !ok {
+			continue
+		}
+		idx := -1
+		for i, mf := range schema {
+			if mf.GetField().Name == name {
+				idx = i
+			}
+		}
+		if rule.Skip {
+			if idx >= 0 && sforce.IsRequired(field) {
+				log.Printf("%v.%v is required, it can't be skipped", obj, name)
+			} else if idx >= 0 {
+				schema = append(schema[:idx], schema[idx+1:]...)
+			}
+			continue
+		}
+		if mf := mockTypeForRule(rule, field); mf != nil {
+			if !field["createable"].(bool) {
+				log.Printf("%v.%v can't be created, its rule has been ignored", obj, name)
+				continue
+			}
+			if mf.GetField().Formula == "" {
+				setFormula(mf.GetField())
+			}
+			if idx >= 0 {
+				schema[idx] = mf
+			} else {
+				schema = append(schema, mf)
+				idx = len(schema) - 1
+			}
+		}
+		if idx >= 0 {
+			schema[idx].GetField().PercentBlank = rule.PercentBlank
+		}
+	}
+	return schema
+}
+
+// returns the mockaroo type for a rule, nil if the rule doesn't change the type
+func mockTypeForRule(rule rules.Rule, field map[string]interface{}) types.IField {
+	switch {
+	case rule.Value != nil:
+		l := types.NewCustomList(field)
+		l.Values = []string{*rule.Value}
+		return l
+	case len(rule.Values) > 0:
+		l := types.NewCustomList(field)
+		l.Values = rule.Values
+		return l
+	case rule.Generator != "":
+		params := make(map[string]interface{})
+		for k, v := range rule.Params {
+			params[k] = v
+		}
+		g := types.NewGeneric(field, rule.Generator, params)
+		// a formula in the params replaces the one that trims the value to the field length
+		if formula, ok := params["formula"].(string); ok {
+			g.Formula = formula
+			delete(params, "formula")
+		}
+		return g
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package mockaroo
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/staging"
+)
+
+/*
+	Schemas and datasets kept in mockaroo, so they can be shared and versioned there rather than in
+	the schemas built here from describe.
+
+	A saved schema is fetched by name. Its columns are matched to the fields of the object, those that
+	aren't fields are dropped. With MergeLocal the fields the saved schema doesn't have are generated
+	as they would be without it and added to each row, the saved schema's values win where both have
+	a field.
+*/
+
+// fetches count records of the saved schema into name.csv, merged with the local fields if asked.
+// A saved schema is for one kind of record, it can't make person accounts
+func (r *MockarooRequest) fetchSaved(name string, count int, personAccounts bool) ([]types.IField, string, error) {
+	if personAccounts {
+		return nil, "", fmt.Errorf("the saved schema %v can't make person accounts", r.SavedSchema)
+	}
+	if r.Cfg.Mockaroo.Key == "" {
+		return nil, "", fmt.Errorf("MOCKAROO_KEY is needed to fetch the saved schema %v", r.SavedSchema)
+	}
+	query := "&schema=" + url.QueryEscape(r.SavedSchema)
+	path, err := r.fetchBatches(name, count, query, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("saved schema %v : %v", r.SavedSchema, err)
+	}
+	saved, err := file.ReadCsv(path)
+	if err != nil {
+		return nil, "", err
+	}
+	fields := (*r.SObject)["fields"].([]interface{})
+	saved, dropped := matchColumns(saved, fields)
+	if len(dropped) > 0 {
+		log.Printf("Dropped the columns of %v that aren't %v fields : %v", r.SavedSchema, (*r.SObject)["name"], strings.Join(dropped, ", "))
+	}
+
+	// the columns of the saved schema, they keep their values when merged
+	savedCols := append([]string(nil), saved[0]...)
+
+	var schema []types.IField
+	if r.MergeLocal {
+		local, localPath, err := r.generate(name+"-local", count, personAccounts)
+		if err != nil {
+			return nil, "", err
+		}
+		rows, err := file.ReadCsv(localPath)
+		if err != nil {
+			return nil, "", err
+		}
+		if saved, err = joinColumns(saved, rows); err != nil {
+			return nil, "", err
+		}
+		for _, f := range local {
+			if indexOf(savedCols, f.GetField().Name) < 0 {
+				schema = append(schema, f)
+			}
+		}
+	}
+	// the saved columns join the schema, so their lookups are populated like any other
+	for _, col := range savedCols {
+		if meta := fieldMeta(col, fields); meta != nil {
+			w := types.NewWords(meta)
+			w.Max = 0
+			w.Min = 0
+			schema = append(schema, w)
+		}
+	}
+	if path, err = file.WriteCsv(fmt.Sprintf("%v%v.csv", r.Cfg.Mockaroo.DataDir, name), saved); err != nil {
+		return nil, "", err
+	}
+	staging.RecordFile(r.Cfg, staging.Generated, (*r.SObject)["name"].(string), name, path)
+	return schema, path, nil
+}
+
+// keeps the columns named for a field of the object, renamed to the field's name as describe has it,
+// and returns the names of those dropped
+func matchColumns(data [][]string, fields []interface{}) ([][]string, []string) {
+	var keep []int
+	var header, dropped []string
+	for i, col := range data[0] {
+		meta := fieldMeta(col, fields)
+		if meta == nil {
+			dropped = append(dropped, col)
+			continue
+		}
+		keep = append(keep, i)
+		header = append(header, meta["name"].(string))
+	}
+	out := [][]string{header}
+	for _, row := range data[1:] {
+		r := make([]string, len(keep))
+		for j, i := range keep {
+			if i < len(row) {
+				r[j] = row[i]
+			}
+		}
+		out = append(out, r)
+	}
+	return out, dropped
+}
+
+// adds the columns of local that saved doesn't have to each row of saved
+func joinColumns(saved, local [][]string) ([][]string, error) {
+	if len(saved) != len(local) {
+		return nil, fmt.Errorf("the saved schema returned %d rows and the local fields %d", len(saved)-1, len(local)-1)
+	}
+	var add []int
+	for i, col := range local[0] {
+		if indexOf(saved[0], col) < 0 {
+			add = append(add, i)
+		}
+	}
+	out := make([][]string, len(saved))
+	for r := range saved {
+		row := append([]string(nil), saved[r]...)
+		for _, i := range add {
+			row = append(row, local[r][i])
+		}
+		out[r] = row
+	}
+	return out, nil
+}
+
+// the describe metadata of the field, matching its name without regard to case
+func fieldMeta(name string, fields []interface{}) map[string]interface{} {
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		if strings.EqualFold(field["name"].(string), strings.TrimSpace(name)) {
+			return field
+		}
+	}
+	return nil
+}
+
+func indexOf(cols []string, name string) int {
+	for i, c := range cols {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// uploads a CSV to mockaroo as a dataset, schemas saved there can then use it by name. A dataset of
+// the same name is replaced
+func UploadDataset(key string, name string, path string) error {
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Content-Type": "text/csv"}
+	u := fmt.Sprintf("%v/datasets/%v?key=%v", apiUrl, url.PathEscape(name), url.QueryEscape(key))
+	if _, _, err := doHttp(u, "", b, "POST", headers); err != nil {
+		return fmt.Errorf("uploading %v as the dataset %v : %v", path, name, err)
+	}
+	log.Printf("Uploaded %v as the mockaroo dataset %v", path, name)
+	return nil
+}
@@ -2,9 +2,9 @@ package mockaroo
 
 import (
 	"log"
-	"time"
 
 	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/random"
 )
 
 func getSchemaForTask(fields []interface{}, personAccounts bool) []types.IField {
@@ -27,7 +27,8 @@ func getSchemaForTask(fields []interface{}, personAccounts bool) []types.IField
 				mf = s
 			case "CompletedDateTime":
 				dt := types.NewDatetime(field)
-				dt.Max = time.Now().Format("01-02-2006")
+				dt.Max = random.Now().Format("01-02-2006")
+				mf = dt
 			case "ActivityDate":
 				dt := types.NewDUNSNumber(field)
 				dt.Formula = "if random(0,10) <= 7 then (DateTime.now - random(1,365)).iso8601 else (DateTime.now + random(1,365)).iso8601 end"
@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
 type Boolean struct {
 	*Field
 }
@@ -10,6 +16,11 @@ func (b Boolean) GetField() *Field {
 func (b Boolean) SetFormula(f string) {
 	b.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (b Boolean) Generate() string {
+	return strconv.FormatBool(random.Intn(2) == 1)
+}
 func NewBoolean(m map[string]interface{}) *Boolean {
 	return &Boolean{
 		Field: &Field{
@@ -10,6 +10,11 @@ func (b Buzzword) GetField() *Field {
 func (b Buzzword) SetFormula(f string) {
 	b.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (b Buzzword) Generate() string {
+	return pick("buzzwords")
+}
 func NewBuzzword(m map[string]interface{}) *Buzzword {
 	return &Buzzword{
 		Field: &Field{
@@ -1,7 +1,12 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type CatchPhrase struct {
 	*Field
+	Sentence bool `json:"-"` // generated locally as a sentence of the run's text model rather than a catch phrase
 }
 
 func (c CatchPhrase) GetField() *Field {
@@ -10,6 +15,14 @@ func (c CatchPhrase) GetField() *Field {
 func (c CatchPhrase) SetFormula(f string) {
 	c.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (c CatchPhrase) Generate() string {
+	if c.Sentence {
+		return lorem.TextSentence()
+	}
+	return pick("catch_adjectives") + " " + pick("catch_descriptors") + " " + pick("catch_nouns")
+}
 func NewCatchPhrase(m map[string]interface{}) *CatchPhrase {
 	return &CatchPhrase{
 		Field: &Field{
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type City struct {
 	*Field
 }
@@ -11,6 +15,16 @@ func (c City) SetFormula(f string) {
 	c.Formula = f
 }
 
+// generates a value without calling mockaroo, from one of the run's locales
+func (c City) Generate() string {
+	return c.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (c City) GenerateFor(loc *lorem.Locale) string {
+	return loc.City()
+}
+
 func NewCity(m map[string]interface{}) *City {
 	return &City{
 		Field: &Field{
@@ -10,6 +10,11 @@ func (c ConstructionSubContract) GetField() *Field {
 func (c ConstructionSubContract) SetFormula(f string) {
 	c.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (c ConstructionSubContract) Generate() string {
+	return pick("construction_subcontracts")
+}
 func NewConstructionSubContract(m map[string]interface{}) *ConstructionSubContract {
 	return &ConstructionSubContract{
 		Field: &Field{
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/random"
+)
+
 type Country struct {
 	*Field
 	RestrictTo []string `json:"countries"`
@@ -11,6 +16,19 @@ func (c Country) GetField() *Field {
 func (c Country) SetFormula(f string) {
 	c.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (c Country) Generate() string {
+	return c.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (c Country) GenerateFor(loc *lorem.Locale) string {
+	if len(c.RestrictTo) > 0 {
+		return c.RestrictTo[random.Intn(len(c.RestrictTo))]
+	}
+	return loc.Country
+}
 func NewCountry(m map[string]interface{}) *Country {
 	return &Country{
 		Field: &Field{
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/random"
+)
+
 type CustomList struct {
 	*Field
 	Distribution   string   `json:"distribution"`
@@ -15,6 +19,14 @@ func (c CustomList) SetFormula(f string) {
 	c.Formula = f
 }
 
+// generates a value without calling mockaroo
+func (c CustomList) Generate() string {
+	if len(c.Values) == 0 {
+		return ""
+	}
+	return c.Values[random.Intn(len(c.Values))]
+}
+
 func NewCustomList(m map[string]interface{}) *CustomList {
 	return &CustomList{
 		Field: &Field{
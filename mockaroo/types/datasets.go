@@ -0,0 +1,52 @@
+package types
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+	The lists the native generator picks from, one value per line in data/<name>.txt
+*/
+
+//go:embed data/*.txt
+var datasetFiles embed.FS
+
+var datasets sync.Map
+
+// returns the values of the named dataset, it panics on a name that isn't embedded
+func dataset(name string) []string {
+	if d, ok := datasets.Load(name); ok {
+		return d.([]string)
+	}
+	b, err := datasetFiles.ReadFile(fmt.Sprintf("data/%v.txt", name))
+	if err != nil {
+		panic(fmt.Sprintf("no dataset %v", name))
+	}
+	var values []string
+	for _, l := range strings.Split(string(b), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			values = append(values, l)
+		}
+	}
+	datasets.Store(name, values)
+	return values
+}
+
+// returns a random value from the named dataset
+func pick(name string) string {
+	d := dataset(name)
+	return d[random.Intn(len(d))]
+}
+
+// returns a number from min to max inclusive
+func between(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + random.Intn(max-min+1)
+}
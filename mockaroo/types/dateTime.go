@@ -1,6 +1,11 @@
 package types
 
-import "time"
+import (
+	"time"
+
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/temporal"
+)
 
 type Datetime struct {
 	*Field
@@ -15,6 +20,23 @@ func (d Datetime) SetFormula(f string) {
 	d.Formula = f
 }
 
+// generates a value without calling mockaroo, written as salesforce takes a date or a datetime
+func (d Datetime) Generate() string {
+	min, err := time.Parse("01/02/2006", d.Min)
+	if err != nil {
+		min = random.Now().AddDate(-1, 0, 0)
+	}
+	max, err := time.Parse("01/02/2006", d.Max)
+	if err != nil || max.Before(min) {
+		max = min.AddDate(1, 0, 0)
+	}
+	v := min.Add(time.Duration(random.Int63n(int64(max.Sub(min)) + 1))).UTC()
+	if t, _ := d.SforceMeta["type"].(string); t == "date" {
+		return v.Format(temporal.DateFormat)
+	}
+	return v.Truncate(time.Second).Format(temporal.DateTimeFormat)
+}
+
 func NewDatetime(m map[string]interface{}) *Datetime {
 
 	return &Datetime{
@@ -23,7 +45,7 @@ func NewDatetime(m map[string]interface{}) *Datetime {
 			SforceMeta: m,
 			FieldType:  "Datetime",
 		},
-		Min: time.Now().AddDate(-1, 0, 0).Format("01/02/2006"),
-		Max: time.Now().AddDate(1, 0, 0).Format("01/02/2006"),
+		Min: random.Now().AddDate(-1, 0, 0).Format("01/02/2006"),
+		Max: random.Now().AddDate(1, 0, 0).Format("01/02/2006"),
 	}
 }
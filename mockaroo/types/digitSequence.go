@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type DigitSequence struct {
 	*Field
 	Format string `json:"format"`
@@ -12,6 +16,11 @@ func (d DigitSequence) SetFormula(f string) {
 	d.Formula = f
 }
 
+// generates a value without calling mockaroo
+func (d DigitSequence) Generate() string {
+	return lorem.Sequence(d.Format)
+}
+
 /*
 Format
 	Use "#" for a random digit.
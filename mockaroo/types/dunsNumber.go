@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type DUNSNumber struct {
 	*Field
 }
@@ -10,6 +14,11 @@ func (d DUNSNumber) GetField() *Field {
 func (d DUNSNumber) SetFormula(f string) {
 	d.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (d DUNSNumber) Generate() string {
+	return lorem.Sequence("##-###-####")
+}
 func NewDUNSNumber(m map[string]interface{}) *DUNSNumber {
 	return &DUNSNumber{
 		Field: &Field{
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type EmailAddress struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (e EmailAddress) GetField() *Field {
 func (e EmailAddress) SetFormula(f string) {
 	e.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (e EmailAddress) Generate() string {
+	return e.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (e EmailAddress) GenerateFor(loc *lorem.Locale) string {
+	return loc.Email()
+}
 func NewEmailAddress(m map[string]interface{}) *EmailAddress {
 	return &EmailAddress{
 		Field: &Field{
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/random"
+)
+
 type FakeCompanyName struct {
 	*Field
 }
@@ -10,6 +15,19 @@ func (fcn FakeCompanyName) GetField() *Field {
 func (fcn FakeCompanyName) SetFormula(f string) {
 	fcn.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (fcn FakeCompanyName) Generate() string {
+	return fcn.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (fcn FakeCompanyName) GenerateFor(loc *lorem.Locale) string {
+	if random.Intn(3) == 0 {
+		return loc.LastName() + "-" + loc.LastName()
+	}
+	return loc.Company()
+}
 func NewFakeCompanyName(m map[string]interface{}) *FakeCompanyName {
 	return &FakeCompanyName{
 		Field: &Field{
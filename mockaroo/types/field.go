@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 /*
 	Define a base set of attributes for a mockaroo field and the interface
 	that all types must implement
@@ -18,4 +22,75 @@ type Field struct {
 type IField interface {
 	GetField() *Field
 	SetFormula(f string)
+	Generate() string // a value made locally, for when mockaroo isn't used
+}
+
+// a field whose values depend on the locale, a name, an address or a company
+type Localised interface {
+	GenerateFor(loc *lorem.Locale) string
+}
+
+// returns a new field of the mockaroo type, nil if there isn't a local implementation of it
+func NewForType(mockType string, m map[string]interface{}) IField {
+	switch mockType {
+	case "Boolean":
+		return NewBoolean(m)
+	case "Buzzword":
+		return NewBuzzword(m)
+	case "Catch Phrase":
+		return NewCatchPhrase(m)
+	case "City":
+		return NewCity(m)
+	case "Construction Subcontract Category":
+		return NewConstructionSubContract(m)
+	case "Country":
+		return NewCountry(m)
+	case "Custom List":
+		return NewCustomList(m)
+	case "Datetime":
+		return NewDatetime(m)
+	case "Digit Sequence":
+		return NewDigitSequence(m)
+	case "DUNS Number":
+		return NewDUNSNumber(m)
+	case "Email Address":
+		return NewEmailAddress(m)
+	case "Fake Company Name":
+		return NewFakeCompanyName(m)
+	case "First Name":
+		return NewFirstName(m)
+	case "Full Name":
+		return NewFullName(m)
+	case "GUID":
+		return NewGUID(m)
+	case "Job Title":
+		return NewJobTitle(m)
+	case "Last Name":
+		return NewLastName(m)
+	case "Latitude":
+		return NewLatitude(m)
+	case "Longitude":
+		return NewLongitude(m)
+	case "Number":
+		return NewNumber(m)
+	case "Phone":
+		return NewPhone(m)
+	case "Postal Code":
+		return NewPostalCode(m)
+	case "Sentences":
+		return NewSentences(m)
+	case "State":
+		return NewState(m)
+	case "Stock Symbol":
+		return NewStockSymbol(m)
+	case "Street Address":
+		return NewStreetAddress(m)
+	case "Street Name":
+		return NewStreetName(m)
+	case "URL":
+		return NewURL(m)
+	case "Words":
+		return NewWords(m)
+	}
+	return nil
 }
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type FirstName struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (fn FirstName) GetField() *Field {
 func (fn FirstName) SetFormula(f string) {
 	fn.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (fn FirstName) Generate() string {
+	return fn.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (fn FirstName) GenerateFor(loc *lorem.Locale) string {
+	return loc.FirstName()
+}
 func NewFirstName(m map[string]interface{}) *FirstName {
 	return &FirstName{
 		Field: &Field{
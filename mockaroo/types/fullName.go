@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type FullName struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (fn FullName) GetField() *Field {
 func (fn FullName) SetFormula(f string) {
 	fn.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (fn FullName) Generate() string {
+	return fn.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (fn FullName) GenerateFor(loc *lorem.Locale) string {
+	return loc.FullName()
+}
 func NewFullName(m map[string]interface{}) *FullName {
 	return &FullName{
 		Field: &Field{
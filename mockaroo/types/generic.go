@@ -0,0 +1,68 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// any mockaroo type, its settings are sent as they were given
+type Generic struct {
+	*Field
+	Params map[string]interface{}
+}
+
+func (g Generic) GetField() *Field {
+	return g.Field
+}
+func (g Generic) SetFormula(f string) {
+	g.Formula = f
+}
+
+// generates a value as the type it names would, or blank if there isn't a local implementation of it
+func (g Generic) Generate() string {
+	f, err := g.Resolve()
+	if err != nil {
+		return ""
+	}
+	return f.Generate()
+}
+
+// returns the field as the type it names, with the params set on it
+func (g Generic) Resolve() (IField, error) {
+	f := NewForType(g.FieldType, g.SforceMeta)
+	if f == nil {
+		return nil, fmt.Errorf("%v has no local generator, it needs mockaroo", g.FieldType)
+	}
+	b, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("the params of %v %v : %v", g.FieldType, g.Name, err)
+	}
+	return f, nil
+}
+
+// the params sit alongside the name and type, as they do for the other types
+func (g Generic) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{})
+	for k, v := range g.Params {
+		m[k] = v
+	}
+	m["name"] = g.Name
+	m["type"] = g.FieldType
+	m["percentBlank"] = g.PercentBlank
+	m["formula"] = g.Formula
+	return json.Marshal(m)
+}
+
+func NewGeneric(m map[string]interface{}, mockType string, params map[string]interface{}) *Generic {
+	return &Generic{
+		Field: &Field{
+			Name:       m["name"].(string),
+			SforceMeta: m,
+			FieldType:  mockType,
+		},
+		Params: params,
+	}
+}
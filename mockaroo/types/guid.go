@@ -1,5 +1,11 @@
 package types
 
+import (
+	"fmt"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
 type GUID struct {
 	*Field
 }
@@ -11,6 +17,15 @@ func (g GUID) SetFormula(f string) {
 	g.Formula = f
 }
 
+// generates a value without calling mockaroo
+func (g GUID) Generate() string {
+	b := make([]byte, 16)
+	random.Read(b)
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
 func NewGUID(m map[string]interface{}) *GUID {
 	return &GUID{
 		Field: &Field{
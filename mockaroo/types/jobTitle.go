@@ -10,6 +10,11 @@ func (j JobTitle) GetField() *Field {
 func (j JobTitle) SetFormula(f string) {
 	j.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (j JobTitle) Generate() string {
+	return pick("job_titles")
+}
 func NewJobTitle(m map[string]interface{}) *JobTitle {
 	return &JobTitle{
 		Field: &Field{
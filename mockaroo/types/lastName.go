@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type LastName struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (l LastName) GetField() *Field {
 func (l LastName) SetFormula(f string) {
 	l.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (l LastName) Generate() string {
+	return l.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (l LastName) GenerateFor(loc *lorem.Locale) string {
+	return loc.LastName()
+}
 func NewLastName(m map[string]interface{}) *LastName {
 	return &LastName{
 		Field: &Field{
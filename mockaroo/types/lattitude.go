@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
 type Latitude struct {
 	*Field
 }
@@ -10,6 +16,11 @@ func (l Latitude) GetField() *Field {
 func (l Latitude) SetFormula(f string) {
 	l.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (l Latitude) Generate() string {
+	return strconv.FormatFloat(random.Float64()*180-90, 'f', 6, 64)
+}
 func NewLatitude(m map[string]interface{}) *Latitude {
 	return &Latitude{
 		Field: &Field{
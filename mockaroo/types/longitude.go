@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
 type Longitude struct {
 	*Field
 }
@@ -10,6 +16,11 @@ func (l Longitude) GetField() *Field {
 func (l Longitude) SetFormula(f string) {
 	l.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (l Longitude) Generate() string {
+	return strconv.FormatFloat(random.Float64()*360-180, 'f', 6, 64)
+}
 func NewLongitude(m map[string]interface{}) *Longitude {
 	return &Longitude{
 		Field: &Field{
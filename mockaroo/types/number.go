@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+
+	"github.com/troysellers/go-modifier/random"
+)
+
 type Number struct {
 	*Field
 	Decimals int `json:"decimals"`
@@ -13,6 +19,12 @@ func (n Number) GetField() *Field {
 func (n Number) SetFormula(f string) {
 	n.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (n Number) Generate() string {
+	v := float64(n.Min) + random.Float64()*float64(n.Max-n.Min)
+	return strconv.FormatFloat(v, 'f', n.Decimals, 64)
+}
 func NewNumber(m map[string]interface{}) *Number {
 	return &Number{
 		Field: &Field{
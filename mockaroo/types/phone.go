@@ -1,5 +1,12 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
+// the Format a Phone is made with, it means a number of the locale rather than this format
+const DefaultPhoneFormat = "+# ### ### ####"
+
 type Phone struct {
 	*Field
 	Format string `json:"format"`
@@ -12,6 +19,20 @@ func (p Phone) SetFormula(f string) {
 	p.Formula = f
 }
 
+// generates a value without calling mockaroo, from one of the run's locales
+func (p Phone) Generate() string {
+	return p.GenerateFor(lorem.PickLocale())
+}
+
+// generates a number of the locale's country in the run's phone format, unless a rule has set
+// Format to something other than DefaultPhoneFormat
+func (p Phone) GenerateFor(loc *lorem.Locale) string {
+	if p.Format != "" && p.Format != DefaultPhoneFormat {
+		return lorem.Sequence(p.Format)
+	}
+	return loc.Phone()
+}
+
 /*
 Format must be one of these
 	###-###-####
@@ -30,6 +51,6 @@ func NewPhone(m map[string]interface{}) *Phone {
 			SforceMeta: m,
 			FieldType:  "Phone",
 		},
-		Format: "+# ### ### ####",
+		Format: DefaultPhoneFormat,
 	}
 }
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type PostalCode struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (p PostalCode) GetField() *Field {
 func (p PostalCode) SetFormula(f string) {
 	p.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (p PostalCode) Generate() string {
+	return p.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (p PostalCode) GenerateFor(loc *lorem.Locale) string {
+	return loc.PostalCode()
+}
 func NewPostalCode(m map[string]interface{}) *PostalCode {
 	return &PostalCode{
 		Field: &Field{
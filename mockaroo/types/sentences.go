@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strings"
+
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type Sentences struct {
 	*Field
 	Max int `json:"max"`
@@ -12,6 +18,15 @@ func (s Sentences) GetField() *Field {
 func (s Sentences) SetFormula(f string) {
 	s.Formula = f
 }
+
+// generates a value without calling mockaroo, from the run's text model
+func (s Sentences) Generate() string {
+	var out []string
+	for i := between(s.Min, s.Max); i > 0; i-- {
+		out = append(out, lorem.TextSentence())
+	}
+	return strings.Join(out, " ")
+}
 func NewSentences(m map[string]interface{}) *Sentences {
 	return &Sentences{
 		Field: &Field{
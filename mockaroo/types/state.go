@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type State struct {
 	*Field
 	OnlyUS bool `json:"onlyUSPlaces"`
@@ -11,6 +15,19 @@ func (s State) GetField() *Field {
 func (s State) SetFormula(f string) {
 	s.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (s State) Generate() string {
+	return s.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (s State) GenerateFor(loc *lorem.Locale) string {
+	if s.OnlyUS {
+		loc, _ = lorem.GetLocale("en_US")
+	}
+	return loc.State()
+}
 func NewState(m map[string]interface{}) *State {
 	return &State{
 		Field: &Field{
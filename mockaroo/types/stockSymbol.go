@@ -10,6 +10,11 @@ func (s StockSymbol) GetField() *Field {
 func (s StockSymbol) SetFormula(f string) {
 	s.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (s StockSymbol) Generate() string {
+	return pick("stock_symbols")
+}
 func NewStockSymbol(m map[string]interface{}) *StockSymbol {
 	return &StockSymbol{
 		Field: &Field{
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type StreetAddress struct {
 	*Field
 }
@@ -10,6 +14,16 @@ func (s StreetAddress) GetField() *Field {
 func (s StreetAddress) SetFormula(f string) {
 	s.Formula = f
 }
+
+// generates a value without calling mockaroo, from one of the run's locales
+func (s StreetAddress) Generate() string {
+	return s.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (s StreetAddress) GenerateFor(loc *lorem.Locale) string {
+	return loc.Street()
+}
 func NewStreetAddress(m map[string]interface{}) *StreetAddress {
 	return &StreetAddress{
 		Field: &Field{
@@ -1,5 +1,9 @@
 package types
 
+import (
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type StreetName struct {
 	*Field
 }
@@ -11,6 +15,16 @@ func (s StreetName) SetFormula(f string) {
 	s.Formula = f
 }
 
+// generates a value without calling mockaroo, from one of the run's locales
+func (s StreetName) Generate() string {
+	return s.GenerateFor(lorem.PickLocale())
+}
+
+// generates a value from the locale, so the fields of a record can agree
+func (s StreetName) GenerateFor(loc *lorem.Locale) string {
+	return loc.StreetName()
+}
+
 func NewStreetName(m map[string]interface{}) *StreetName {
 	return &StreetName{
 		Field: &Field{
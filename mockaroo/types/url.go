@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strings"
+
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type URL struct {
 	*Field
 	IncludeHost        bool `json:"includeHost"`
@@ -14,6 +20,24 @@ func (u URL) GetField() *Field {
 func (u URL) SetFormula(f string) {
 	u.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (u URL) Generate() string {
+	var sb strings.Builder
+	if u.IncludeProtocol {
+		sb.WriteString("http://")
+	}
+	if u.IncludeHost {
+		sb.WriteString("www." + lorem.Word(4, 10) + "." + pick("tlds"))
+	}
+	if u.IncludePath {
+		sb.WriteString("/" + lorem.Word(3, 8) + "/" + lorem.Word(3, 8))
+	}
+	if u.IncludeQueryString {
+		sb.WriteString("?" + lorem.Word(3, 6) + "=" + lorem.Word(3, 6))
+	}
+	return sb.String()
+}
 func NewURL(m map[string]interface{}) *URL {
 	return &URL{
 		Field: &Field{
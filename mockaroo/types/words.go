@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strings"
+
+	"github.com/troysellers/go-modifier/lorem"
+)
+
 type Words struct {
 	*Field
 	Max int `json:"max"`
@@ -12,6 +18,15 @@ func (w Words) GetField() *Field {
 func (w Words) SetFormula(f string) {
 	w.Formula = f
 }
+
+// generates a value without calling mockaroo
+func (w Words) Generate() string {
+	var out []string
+	for i := between(w.Min, w.Max); i > 0; i-- {
+		out = append(out, lorem.Word(2, 10))
+	}
+	return strings.Join(out, " ")
+}
 func NewWords(m map[string]interface{}) *Words {
 	return &Words{
 		Field: &Field{
@@ -0,0 +1,315 @@
+package orgcopy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+	Copying records between orgs, they are loaded so parents come before their children and
+	every lookup is rewritten from the source Id to the Id the record was given in the target.
+
+	The old to new Id map is saved between runs, so running a copy again updates the records
+	it copied before rather than inserting them again. The map belongs to one source and target
+	org, it is refused for any other pair (a refreshed sandbox is a new org).
+*/
+
+// returns the objects ordered so each comes after the objects it looks up.
+// refs holds the objects each object references, references outside the set (and to itself) are ignored.
+// Objects in a cycle keep the order they were given in, their lookups are set in a second pass.
+func Order(objects []string, refs map[string][]string) []string {
+	inSet := make(map[string]string)
+	for _, o := range objects {
+		inSet[strings.ToLower(o)] = o
+	}
+	// how many unloaded parents each object is waiting on
+	waiting := make(map[string]map[string]bool)
+	for _, o := range objects {
+		waiting[o] = make(map[string]bool)
+		for _, r := range refs[o] {
+			if p, ok := inSet[strings.ToLower(r)]; ok && !strings.EqualFold(p, o) {
+				waiting[o][p] = true
+			}
+		}
+	}
+	var order []string
+	done := make(map[string]bool)
+	for len(order) < len(objects) {
+		progress := false
+		for _, o := range objects {
+			if done[o] || len(waiting[o]) > 0 {
+				continue
+			}
+			order = append(order, o)
+			done[o] = true
+			progress = true
+			for _, w := range waiting {
+				delete(w, o)
+			}
+		}
+		if !progress {
+			// a cycle, take the first of its objects
+			o := inCycle(objects, done, waiting)
+			order = append(order, o)
+			done[o] = true
+			for _, w := range waiting {
+				delete(w, o)
+			}
+		}
+	}
+	return order
+}
+
+// follows the parents of the first object left until one repeats, and returns the object of
+// that cycle that came first in objects
+func inCycle(objects []string, done map[string]bool, waiting map[string]map[string]bool) string {
+	pos := make(map[string]int)
+	for i, o := range objects {
+		pos[o] = i
+	}
+	var path []string
+	seen := make(map[string]int)
+	var o string
+	for _, x := range objects {
+		if !done[x] {
+			o = x
+			break
+		}
+	}
+	for {
+		if at, ok := seen[o]; ok {
+			first := o
+			for _, c := range path[at:] {
+				if pos[c] < pos[first] {
+					first = c
+				}
+			}
+			return first
+		}
+		seen[o] = len(path)
+		path = append(path, o)
+		// every object left is waiting on another, take the parent that came first
+		next := ""
+		for p := range waiting[o] {
+			if next == "" || pos[p] < pos[next] {
+				next = p
+			}
+		}
+		o = next
+	}
+}
+
+// the source Id to target Id of every record copied between two orgs, saved as json
+type IdMap struct {
+	path   string
+	mu     sync.Mutex
+	source string // the Ids of the orgs
+	target string
+	ids    map[string]string
+}
+
+// how the map is saved
+type idMapFile struct {
+	Source string            `json:"source"`
+	Target string            `json:"target"`
+	Ids    map[string]string `json:"ids"`
+}
+
+// loads the map saved at path for copies from the source org to the target org, a missing file
+// is an empty map. A map saved for other orgs is an error.
+func OpenIdMap(path string, source string, target string) (*IdMap, error) {
+	m := &IdMap{path: path, source: source, target: target, ids: make(map[string]string)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved idMapFile
+	if err := json.Unmarshal(b, &saved); err != nil || saved.Ids == nil {
+		return nil, fmt.Errorf("unable to read the Id map %v, it isn't one saved for a pair of orgs. Use another -idmap or delete it to start again", path)
+	}
+	if !sameOrg(saved.Source, source) || !sameOrg(saved.Target, target) {
+		return nil, fmt.Errorf("the Id map %v is for copies from %v to %v, not %v to %v. Use another -idmap or delete it to start again", path, saved.Source, saved.Target, source, target)
+	}
+	m.ids = saved.Ids
+	return m, nil
+}
+
+// compares org Ids, 15 character Ids match their 18 character form
+func sameOrg(a, b string) bool {
+	if len(a) > 15 {
+		a = a[:15]
+	}
+	if len(b) > 15 {
+		b = b[:15]
+	}
+	return a == b
+}
+
+// returns the target Id for a source Id. 15 character Ids match their 18 character form.
+func (m *IdMap) Get(old string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(old) > 15 {
+		old = old[:15]
+	}
+	id, ok := m.ids[old]
+	return id, ok
+}
+
+func (m *IdMap) Set(old string, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(old) > 15 {
+		old = old[:15]
+	}
+	m.ids[old] = id
+}
+
+func (m *IdMap) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.ids)
+}
+
+// writes the map back to disk
+func (m *IdMap) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := json.MarshalIndent(idMapFile{Source: m.source, Target: m.target, Ids: m.ids}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, b, 0644)
+}
+
+// a lookup that couldn't be set when its record was loaded, the parent hadn't been copied yet
+type Deferred struct {
+	Field  string
+	OldId  string // the source Id of the record
+	OldRef string // the source Id it looks up
+}
+
+// the records of one object ready to load into the target
+type Batch struct {
+	Data       [][]string // Id (the target Id, blank to insert) then the fields
+	OldIds     []string   // the source Id of each row
+	Deferred   []Deferred
+	Unresolved int // lookups to records that aren't being copied, left blank
+}
+
+// rewrites source rows (header first, with an Id column) for the target.
+// refs is each lookup field and the objects it can reference, inSet the objects being copied.
+// Lookups are mapped to their target Ids, lookups to objects being copied that aren't loaded yet are
+// deferred and lookups to anything else are left blank.
+func Rewrite(data [][]string, refs map[string][]string, inSet map[string]bool, ids *IdMap) (*Batch, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+	idCol := -1
+	for i, h := range data[0] {
+		if strings.EqualFold(h, "Id") {
+			idCol = i
+		}
+	}
+	if idCol < 0 {
+		return nil, fmt.Errorf("the source data has no Id column")
+	}
+	lookups := make(map[int][]string)
+	for i, h := range data[0] {
+		for f, to := range refs {
+			if strings.EqualFold(f, h) {
+				lookups[i] = to
+			}
+		}
+	}
+	b := &Batch{Data: [][]string{append([]string{}, data[0]...)}}
+	b.Data[0][idCol] = "Id"
+	for _, row := range data[1:] {
+		out := append([]string{}, row...)
+		old := row[idCol]
+		b.OldIds = append(b.OldIds, old)
+		out[idCol], _ = ids.Get(old)
+		for i, to := range lookups {
+			ref := row[i]
+			if ref == "" {
+				continue
+			}
+			if id, ok := ids.Get(ref); ok {
+				out[i] = id
+				continue
+			}
+			out[i] = ""
+			if referencesAny(to, inSet) {
+				b.Deferred = append(b.Deferred, Deferred{Field: data[0][i], OldId: old, OldRef: ref})
+			} else {
+				b.Unresolved++
+			}
+		}
+		b.Data = append(b.Data, out)
+	}
+	return b, nil
+}
+
+// returns the update rows (Id then the fields) for the deferred lookups that can now be set,
+// and how many still can't be
+func ResolveDeferred(deferred []Deferred, ids *IdMap) ([][]string, int) {
+	var fields []string
+	byRecord := make(map[string]map[string]string)
+	var records []string
+	var unresolved int
+	for _, d := range deferred {
+		id, ok := ids.Get(d.OldId)
+		ref, rok := ids.Get(d.OldRef)
+		if !ok || !rok {
+			unresolved++
+			continue
+		}
+		if byRecord[id] == nil {
+			byRecord[id] = make(map[string]string)
+			records = append(records, id)
+		}
+		byRecord[id][d.Field] = ref
+		if indexOf(fields, d.Field) < 0 {
+			fields = append(fields, d.Field)
+		}
+	}
+	if len(records) == 0 {
+		return nil, unresolved
+	}
+	sort.Strings(fields)
+	data := [][]string{append([]string{"Id"}, fields...)}
+	for _, id := range records {
+		row := []string{id}
+		for _, f := range fields {
+			row = append(row, byRecord[id][f])
+		}
+		data = append(data, row)
+	}
+	return data, unresolved
+}
+
+func referencesAny(to []string, inSet map[string]bool) bool {
+	for _, t := range to {
+		if inSet[strings.ToLower(t)] {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOf(s []string, v string) int {
+	for i, x := range s {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
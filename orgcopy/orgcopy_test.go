@@ -0,0 +1,131 @@
+package orgcopy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOrder(t *testing.T) {
+	refs := map[string][]string{
+		"Case":    {"Account", "Contact", "User"},
+		"Contact": {"Account"},
+		"Account": {"Account", "User"},
+	}
+	got := Order([]string{"Case", "Contact", "Account"}, refs)
+	if diff := cmp.Diff([]string{"Account", "Contact", "Case"}, got); diff != "" {
+		t.Error(diff)
+	}
+	// a cycle keeps the order it was given in
+	cycle := map[string][]string{"A__c": {"B__c"}, "B__c": {"A__c"}, "C__c": {"A__c"}}
+	got = Order([]string{"C__c", "B__c", "A__c"}, cycle)
+	if diff := cmp.Diff([]string{"B__c", "A__c", "C__c"}, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	ids, err := OpenIdMap(filepath.Join(t.TempDir(), "ids.json"), "00D000000000001", "00D000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids.Set("001000000000001AAA", "001T00000000001")
+	ids.Set("003000000000001", "003T00000000001")
+	data := [][]string{
+		{"Id", "LastName", "AccountId", "ReportsToId", "OwnerId"},
+		{"003000000000001AAA", "Smith", "001000000000001AAA", "", "005000000000001"},
+		{"003000000000002AAA", "Jones", "001000000000002AAA", "003000000000003AAA", ""},
+	}
+	refs := map[string][]string{"AccountId": {"Account"}, "ReportsToId": {"Contact"}, "OwnerId": {"User", "Group"}}
+	b, err := Rewrite(data, refs, map[string]bool{"account": true, "contact": true}, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Id", "LastName", "AccountId", "ReportsToId", "OwnerId"},
+		{"003T00000000001", "Smith", "001T00000000001", "", ""},
+		{"", "Jones", "", "", ""},
+	}
+	if diff := cmp.Diff(want, b.Data); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"003000000000001AAA", "003000000000002AAA"}, b.OldIds); diff != "" {
+		t.Error(diff)
+	}
+	if b.Unresolved != 1 {
+		t.Errorf("expected 1 unresolved lookup got %d", b.Unresolved)
+	}
+	wantDeferred := []Deferred{
+		{Field: "AccountId", OldId: "003000000000002AAA", OldRef: "001000000000002AAA"},
+		{Field: "ReportsToId", OldId: "003000000000002AAA", OldRef: "003000000000003AAA"},
+	}
+	if len(b.Deferred) != 2 {
+		t.Fatalf("expected 2 deferred lookups got %v", b.Deferred)
+	}
+	for _, d := range wantDeferred {
+		found := false
+		for _, g := range b.Deferred {
+			found = found || g == d
+		}
+		if !found {
+			t.Errorf("%v wasn't deferred", d)
+		}
+	}
+
+	// once the parents are loaded the deferred lookups can be set
+	ids.Set("003000000000002", "003T00000000002")
+	ids.Set("003000000000003", "003T00000000003")
+	rows, unresolved := ResolveDeferred(b.Deferred, ids)
+	if unresolved != 1 {
+		t.Errorf("expected 1 lookup still unresolved got %d", unresolved)
+	}
+	if diff := cmp.Diff([][]string{{"Id", "ReportsToId"}, {"003T00000000002", "003T00000000003"}}, rows); diff != "" {
+		t.Error(diff)
+	}
+	if _, err := Rewrite([][]string{{"Name"}}, refs, nil, ids); err == nil {
+		t.Error("expected an error without an Id column")
+	}
+}
+
+func TestIdMapSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.json")
+	ids, err := OpenIdMap(path, "00D000000000001", "00D000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids.Set("001000000000001AAA", "001T00000000001AAA")
+	if err := ids.Save(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := OpenIdMap(path, "00D000000000001AAA", "00D000000000002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := again.Get("001000000000001"); !ok || id != "001T00000000001AAA" {
+		t.Errorf("expected the saved Id got %v %v", id, ok)
+	}
+	if again.Len() != 1 {
+		t.Errorf("expected 1 Id got %d", again.Len())
+	}
+}
+
+func TestIdMapOrgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ids.json")
+	ids, _ := OpenIdMap(path, "00D000000000001", "00D000000000002")
+	ids.Set("001000000000001", "001T00000000001")
+	if err := ids.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenIdMap(path, "00D000000000001", "00D000000000003"); err == nil {
+		t.Error("expected an error for another target org")
+	}
+	if _, err := OpenIdMap(path, "00D000000000003", "00D000000000002"); err == nil {
+		t.Error("expected an error for another source org")
+	}
+	os.WriteFile(path, []byte(`{"001000000000001": "001T00000000001"}`), 0644)
+	if _, err := OpenIdMap(path, "00D000000000001", "00D000000000002"); err == nil {
+		t.Error("expected an error for a map without its orgs")
+	}
+}
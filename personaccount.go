@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/mockaroo/types"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/sforce"
+)
+
+// works out how person accounts apply to this run.
+//
+// Returns whether the org has person accounts enabled and, when we are creating
+// person accounts, the record type to give them. When they are enabled, AccountId and ContactId
+// lookups default to business accounts and their contacts, the person account share of
+// the records is related afterwards by updatePersonAccountLookups.
+func setupPersonAccounts(cfg *config.Config, c *simpleforce.Client, obj string, ratio float64) (bool, string, error) {
+	if ratio < 0 || ratio > 1 {
+		return false, "", fmt.Errorf("-personratio must be between 0 and 1, not %v", ratio)
+	}
+	enabled := sforce.HasPersonAccounts(c.SObject("Account").Describe())
+	log.Printf("Person accounts enabled : %v", enabled)
+	if !enabled {
+		if ratio > 0 {
+			return false, "", fmt.Errorf("person accounts are not enabled in this org")
+		}
+		return false, "", nil
+	}
+	cfg.Lookups.SetDefaultFilter(obj, "AccountId", "IsPersonAccount = false")
+	cfg.Lookups.SetDefaultFilter(obj, "ContactId", "IsPersonAccount = false")
+	if ratio == 0 || !strings.EqualFold(obj, "account") {
+		return true, "", nil
+	}
+	rt, err := sforce.GetPersonAccountRecordTypeId(c)
+	if err != nil {
+		return true, "", err
+	}
+	return true, rt, nil
+}
+
+// relates roughly ratio of the rows to person accounts.
+// AccountId gets the person account Id and ContactId gets its PersonContactId, when they are in the schema.
+func updatePersonAccountLookups(cfg *config.Config, f string, schema []types.IField, ratio float64, objIds *sync.Map, c *simpleforce.Client) error {
+	var hasAccount, hasContact bool
+	for _, field := range schema {
+		hasAccount = hasAccount || field.GetField().Name == "AccountId"
+		hasContact = hasContact || field.GetField().Name == "ContactId"
+	}
+	if !hasAccount && !hasContact {
+		return nil
+	}
+	pas, err := sforce.GetPersonAccounts(cfg, c, objIds)
+	if err != nil {
+		return err
+	}
+	accountIds, err := file.GetColumn(f, "AccountId")
+	if err != nil {
+		return err
+	}
+	contactIds, err := file.GetColumn(f, "ContactId")
+	if err != nil {
+		return err
+	}
+	var related int
+	for i := range accountIds {
+		if random.Float64() >= ratio {
+			continue
+		}
+		pa := pas[random.Intn(len(pas))]
+		accountIds[i] = pa.Id
+		contactIds[i] = pa.PersonContactId
+		related++
+	}
+	log.Printf("Related %d of %d rows to person accounts", related, len(accountIds))
+	if hasAccount {
+		if err := file.SetColumn(f, "AccountId", accountIds); err != nil {
+			return err
+		}
+	}
+	if hasContact {
+		if err := file.SetColumn(f, "ContactId", contactIds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
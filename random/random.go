@@ -0,0 +1,126 @@
+package random
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/*
+	The one source every random choice in a run is made from, lorem text, picklist values, the
+	Ids given to lookups, the native generator and the formulas.
+
+	Seeding it makes a run repeatable, the same seed against the same org metadata and records makes
+	the same CSVs. Without a seed it is seeded from the clock and Seed can tell you what was used so
+	the run can be repeated.
+	The functions mirror those of math/rand and are safe to call from more than one goroutine, but
+	choices made concurrently come in whatever order the goroutines run so a seeded run keeps to one.
+*/
+
+var (
+	mu     sync.Mutex
+	seed   = time.Now().UnixNano()
+	seeded bool
+	r      = rand.New(rand.NewSource(seed))
+	day    time.Time // the day dates are made relative to, when given
+)
+
+// starts the source again from s
+func SetSeed(s int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	seed = s
+	seeded = true
+	r = rand.New(rand.NewSource(s))
+}
+
+// returns the seed the source started from
+func Seed() int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return seed
+}
+
+// true if the seed was given, rather than taken from the clock
+func Seeded() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return seeded
+}
+
+func Int() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Int()
+}
+
+func Intn(n int) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Intn(n)
+}
+
+func Int63n(n int64) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Int63n(n)
+}
+
+func Float32() float32 {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Float32()
+}
+
+func Float64() float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Float64()
+}
+
+func NormFloat64() float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.NormFloat64()
+}
+
+func Perm(n int) []int {
+	mu.Lock()
+	defer mu.Unlock()
+	return r.Perm(n)
+}
+
+// swap is called with the lock held, it mustn't make random choices of its own
+func Shuffle(n int, swap func(i, j int)) {
+	mu.Lock()
+	defer mu.Unlock()
+	r.Shuffle(n, swap)
+}
+
+func Read(b []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	r.Read(b)
+}
+
+// sets the day generated dates are made relative to, so a seeded run can be repeated another day
+func SetDate(d time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	day = d.UTC().Truncate(24 * time.Hour)
+}
+
+// the time generated dates are made relative to. That is the day given to SetDate, otherwise a
+// seeded run uses the start of today (UTC) rather than the clock so running it again the same day
+// makes the same dates
+func Now() time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	if !day.IsZero() {
+		return day
+	}
+	if seeded {
+		return time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	return time.Now()
+}
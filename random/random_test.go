@@ -0,0 +1,50 @@
+package random
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetSeed(t *testing.T) {
+	draw := func() []int {
+		var out []int
+		for i := 0; i < 20; i++ {
+			out = append(out, Intn(1000))
+		}
+		out = append(out, Perm(5)...)
+		return out
+	}
+	SetSeed(42)
+	first := draw()
+	SetSeed(42)
+	second := draw()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same choices from the same seed\n%v\n%v", first, second)
+		}
+	}
+	SetSeed(43)
+	third := draw()
+	same := true
+	for i := range first {
+		same = same && first[i] == third[i]
+	}
+	if same {
+		t.Error("expected different choices from another seed")
+	}
+	if Seed() != 43 || !Seeded() {
+		t.Errorf("expected seed 43, got %d", Seed())
+	}
+	if now := Now(); !now.Equal(now.Truncate(24 * time.Hour)) {
+		t.Errorf("expected a seeded run to use the start of the day, got %v", now)
+	}
+}
+
+func TestSetDate(t *testing.T) {
+	defer SetDate(time.Time{})
+	SetSeed(42)
+	SetDate(time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC))
+	if now := Now(); !now.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the start of the day given, got %v", now)
+	}
+}
@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/temporal"
+)
+
+// makes one value each time it is called
+type Generator func() (string, error)
+
+// returns a generator for the rule, nil if the rule doesn't set how values are made.
+// f is the describe of the field, dates and datetimes are formatted by its type.
+func (r Rule) Native(f map[string]interface{}) (Generator, error) {
+	switch {
+	case r.Value != nil:
+		v := *r.Value
+		return func() (string, error) { return v, nil }, nil
+	case len(r.Values) > 0:
+		return pick(r.Values), nil
+	case r.Generator == "":
+		return nil, nil
+	}
+	p := params(r.Params)
+	switch strings.ToLower(strings.ReplaceAll(r.Generator, " ", "")) {
+	case "words":
+		min, max := p.int("min", 1), p.int("max", 5)
+		return func() (string, error) { return words(min, max), nil }, nil
+	case "sentences":
+		min, max := p.int("min", 1), p.int("max", 3)
+		return func() (string, error) { return lorem.Paragraph(min, max), nil }, nil
+	case "paragraphs":
+		min, max := p.int("min", 1), p.int("max", 2)
+		return func() (string, error) {
+			var ps []string
+			for i := 0; i < between(min, max); i++ {
+				ps = append(ps, lorem.Paragraph(3, 6))
+			}
+			return strings.Join(ps, "\n\n"), nil
+		}, nil
+	case "emailaddress", "email":
+		return func() (string, error) { return lorem.Email(), nil }, nil
+	case "url":
+		return func() (string, error) { return lorem.Url(), nil }, nil
+	case "boolean":
+		return func() (string, error) { return strconv.FormatBool(random.Intn(2) == 1), nil }, nil
+	case "number":
+		min, max, decimals := p.float("min", 0), p.float("max", 100), p.int("decimals", 0)
+		if max < min {
+			return nil, fmt.Errorf("Number max %v is less than min %v", max, min)
+		}
+		return func() (string, error) {
+			return strconv.FormatFloat(min+random.Float64()*(max-min), 'f', decimals, 64), nil
+		}, nil
+	case "phone":
+		format := p.string("format", "###-###-####")
+		return func() (string, error) { return lorem.Sequence(format), nil }, nil
+	case "digitsequence":
+		format := p.string("format", "")
+		if format == "" {
+			return nil, fmt.Errorf("Digit Sequence needs a format")
+		}
+		return func() (string, error) { return lorem.Sequence(format), nil }, nil
+	case "customlist":
+		var values []string
+		vs, _ := r.Params["values"].([]interface{})
+		for _, v := range vs {
+			values = append(values, fmt.Sprintf("%v", v))
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("Custom List needs values")
+		}
+		return pick(values), nil
+	case "datetime", "date":
+		min, err := p.date("min", random.Now().UTC().AddDate(-1, 0, 0))
+		if err != nil {
+			return nil, err
+		}
+		max, err := p.date("max", random.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		if max.Before(min) {
+			return nil, fmt.Errorf("Datetime max is before min")
+		}
+		layout := temporal.DateTimeFormat
+		if t, _ := f["type"].(string); t == "date" {
+			layout = temporal.DateFormat
+		}
+		return func() (string, error) {
+			d := min.Add(time.Duration(random.Int63n(int64(max.Sub(min)) + 1))).Truncate(time.Second)
+			return d.Format(layout), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("the %v generator is only available through Mockaroo", r.Generator)
+}
+
+func pick(values []string) Generator {
+	return func() (string, error) { return values[random.Intn(len(values))], nil }
+}
+
+func between(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + random.Intn(max-min+1)
+}
+
+func words(min, max int) string {
+	n := between(min, max)
+	ws := make([]string, n)
+	for i := range ws {
+		ws[i] = lorem.Word(2, 10)
+	}
+	return strings.Join(ws, " ")
+}
+
+// the params of a generator, numbers come from json as float64
+type params map[string]interface{}
+
+func (p params) float(key string, def float64) float64 {
+	switch v := p[key].(type) {
+	case float64:
+		return v
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func (p params) int(key string, def int) int {
+	return int(math.Round(p.float(key, float64(def))))
+}
+
+func (p params) string(key string, def string) string {
+	if v, ok := p[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// dates are written 2006-01-02 or as Mockaroo writes them, 01/02/2006
+func (p params) date(key string, def time.Time) (time.Time, error) {
+	v, ok := p[key].(string)
+	if !ok || v == "" {
+		return def, nil
+	}
+	for _, layout := range []string{temporal.DateFormat, "01/02/2006", time.RFC3339} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%v %v isn't a date", key, v)
+}
@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+Rules say how the values of a field are generated, by update and by create, in place of the
+default for the field's type. They are kept in a JSON file keyed by object then field, * is every object.
+
+	{
+		"Account": {
+			"Phone": {"generator": "Phone", "params": {"format": "(###) ###-####"}, "percentBlank": 20},
+			"Rating": {"values": ["Hot", "Warm", "Cold"]},
+			"Type": {"value": "Customer"},
+			"Description": {"skip": true}
+		},
+		"*": {
+			"Fax": {"percentBlank": 100}
+		}
+	}
+
+A generator is named as Mockaroo names its types (https://www.mockaroo.com/docs) and its params are
+sent along with it. The update command generates values itself and knows Words, Sentences, Paragraphs,
+Email Address, URL, Number, Phone, Digit Sequence, Boolean, Datetime and Custom List.
+*/
+type Rules map[string]map[string]Rule
+
+type Rule struct {
+	Generator    string                 `json:"generator"`
+	Params       map[string]interface{} `json:"params"`
+	PercentBlank int                    `json:"percentBlank"` // 0 to 100
+	Value        *string                `json:"value"`        // every record gets this
+	Values       []string               `json:"values"`       // every record gets one of these
+	Skip         bool                   `json:"skip"`         // leave the field untouched
+}
+
+// reads a rules file
+func Load(path string) (Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Rules
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("unable to read the rules in %v : %v", path, err)
+	}
+	for obj, fields := range r {
+		for field, rule := range fields {
+			if err := rule.check(); err != nil {
+				return nil, fmt.Errorf("%v %v.%v : %v", path, obj, field, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+var loaded Rules
+var loadedPath string
+var loadMu sync.Mutex
+
+// returns the rules in the GENERATION_RULES file, loading them the first time. There are no rules without the setting.
+func Get(cfg *config.Config) (Rules, error) {
+	if cfg.Rules == "" {
+		return nil, nil
+	}
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if loaded != nil && loadedPath == cfg.Rules {
+		return loaded, nil
+	}
+	r, err := Load(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Generating values with the rules in %v", cfg.Rules)
+	loaded, loadedPath = r, cfg.Rules
+	return loaded, nil
+}
+
+// returns the rule for obj.field, or for the field on every object
+func (r Rules) For(obj string, field string) (Rule, bool) {
+	for _, o := range []string{obj, "*"} {
+		for ro, fields := range r {
+			if !strings.EqualFold(ro, o) {
+				continue
+			}
+			for f, rule := range fields {
+				if strings.EqualFold(f, field) {
+					return rule, true
+				}
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+func (r Rule) check() error {
+	if r.PercentBlank < 0 || r.PercentBlank > 100 {
+		return fmt.Errorf("percentBlank must be between 0 and 100")
+	}
+	set := 0
+	for _, b := range []bool{r.Generator != "", r.Value != nil, len(r.Values) > 0, r.Skip} {
+		if b {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("use one of generator, value, values or skip")
+	}
+	if r.Skip && r.PercentBlank > 0 {
+		return fmt.Errorf("a skipped field can't have a percentBlank")
+	}
+	return nil
+}
+
+// true if the rule replaces how values are made, rather than just blanking some of them
+func (r Rule) Generates() bool {
+	return r.Generator != "" || r.Value != nil || len(r.Values) > 0
+}
+
+// true percentBlank times out of 100
+func (r Rule) Blank() bool {
+	return r.PercentBlank > 0 && random.Intn(100) < r.PercentBlank
+}
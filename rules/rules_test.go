@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	os.WriteFile(path, []byte(`{
+		"Account": {"Phone": {"generator": "Phone", "params": {"format": "(###) ###-####"}, "percentBlank": 10}},
+		"*": {"Fax": {"skip": true}, "Phone": {"value": "1"}}
+	}`), 0644)
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule, ok := r.For("account", "phone"); !ok || rule.Generator != "Phone" || rule.PercentBlank != 10 {
+		t.Errorf("expected the Account phone rule got %v %v", rule, ok)
+	}
+	if rule, ok := r.For("Contact", "Phone"); !ok || *rule.Value != "1" {
+		t.Errorf("expected the * phone rule got %v %v", rule, ok)
+	}
+	if rule, ok := r.For("Contact", "Fax"); !ok || !rule.Skip {
+		t.Errorf("expected the * fax rule got %v %v", rule, ok)
+	}
+	if _, ok := r.For("Contact", "Email"); ok {
+		t.Error("expected no rule for Contact.Email")
+	}
+
+	for _, bad := range []string{
+		`{"Account": {"Phone": {"percentBlank": 101}}}`,
+		`{"Account": {"Phone": {"value": "1", "values": ["2"]}}}`,
+		`{"Account": {"Phone": {"skip": true, "percentBlank": 5}}}`,
+		`{"Account": []}`,
+	} {
+		os.WriteFile(path, []byte(bad), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestNative(t *testing.T) {
+	tests := []struct {
+		rule    Rule
+		fType   string
+		pattern string
+	}{
+		{Rule{Generator: "Phone", Params: map[string]interface{}{"format": "(###) ###-####"}}, "phone", `^\(\d{3}\) \d{3}-\d{4}$`},
+		{Rule{Generator: "Digit Sequence", Params: map[string]interface{}{"format": "ACC-^^-####"}}, "string", `^ACC-[A-Z]{2}-\d{4}$`},
+		{Rule{Generator: "Number", Params: map[string]interface{}{"min": float64(10), "max": float64(20), "decimals": float64(2)}}, "currency", `^1\d\.\d\d$|^20\.00$`},
+		{Rule{Generator: "Datetime", Params: map[string]interface{}{"min": "2020-01-01", "max": "2020-01-31"}}, "date", `^2020-01-\d\d$`},
+		{Rule{Generator: "Datetime", Params: map[string]interface{}{"min": "01/01/2020", "max": "01/02/2020"}}, "datetime", `^2020-01-0[12]T\d\d:\d\d:\d\d\.000Z$`},
+		{Rule{Generator: "Custom List", Params: map[string]interface{}{"values": []interface{}{"a", "b"}}}, "picklist", `^[ab]$`},
+		{Rule{Generator: "Words", Params: map[string]interface{}{"min": float64(2), "max": float64(2)}}, "string", `^\S+ \S+$`},
+		{Rule{Values: []string{"Hot", "Cold"}}, "picklist", `^(Hot|Cold)$`},
+		{Rule{Generator: "boolean"}, "boolean", `^(true|false)$`},
+	}
+	for _, tc := range tests {
+		gen, err := tc.rule.Native(map[string]interface{}{"type": tc.fType})
+		if err != nil {
+			t.Errorf("%v : %v", tc.rule.Generator, err)
+			continue
+		}
+		for i := 0; i < 20; i++ {
+			v, err := gen()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !regexp.MustCompile(tc.pattern).MatchString(v) {
+				t.Errorf("%v generated %q which doesn't match %v", tc.rule.Generator, v, tc.pattern)
+				break
+			}
+		}
+	}
+	if gen, err := (Rule{PercentBlank: 50}).Native(nil); gen != nil || err != nil {
+		t.Errorf("expected no generator for a rule that only blanks values got %v", err)
+	}
+	if _, err := (Rule{Generator: "Fake Company Name"}).Native(nil); err == nil {
+		t.Error("expected an error for a generator only mockaroo has")
+	}
+}
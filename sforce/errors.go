@@ -0,0 +1,21 @@
+package sforce
+
+import "fmt"
+
+// returned when a bulk ingest job completes but some of its records failed
+type FailedRecordsError struct {
+	Object    string
+	Operation string
+	Processed int
+	Failed    int
+	File      string // where the failed records and their errors were written
+}
+
+func (e *FailedRecordsError) Error() string {
+	return fmt.Sprintf("%d of %d %v records failed to %v, see %v", e.Failed, e.Processed, e.Object, e.Operation, e.File)
+}
+
+// returns true if some of the records made it into Salesforce
+func (e *FailedRecordsError) Partial() bool {
+	return e.Failed < e.Processed
+}
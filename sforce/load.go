@@ -0,0 +1,39 @@
+package sforce
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/mapping"
+)
+
+// runs the CSV through the mapping (a .json or Data Loader .sdl file) and writes the result
+// to <obj>-<csv name>-mapped.csv in the data directory. Returns the path of the mapped file.
+func MapCSV(cfg *config.Config, csvfile string, obj string, mappingFile string) (string, error) {
+	m, err := mapping.Load(mappingFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := file.ReadCsv(csvfile)
+	if err != nil {
+		return "", err
+	}
+	mapped, err := m.Apply(data)
+	if err != nil {
+		return "", fmt.Errorf("mapping %v with %v : %v", csvfile, mappingFile, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(csvfile), filepath.Ext(csvfile))
+	path, err := file.BuildFilePath(fmt.Sprintf("%v-%v-mapped.csv", obj, name), cfg)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteCsv(path, mapped); err != nil {
+		return "", err
+	}
+	log.Printf("Mapped %d rows of %v to %v", len(mapped)-1, csvfile, path)
+	return path, nil
+}
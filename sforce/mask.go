@@ -0,0 +1,69 @@
+package sforce
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/mask"
+)
+
+// masks the queried records in place rather than replacing them with lorem. Each field uses the policy
+// in MASK_POLICIES or, if it isn't configured, the default for its type. Fields that can't be updated are left alone.
+func (qj *QueryJob) MaskData(cfg *config.Config) error {
+	m, err := mask.New(cfg.Mask.Key)
+	if err != nil {
+		return err
+	}
+	obj := qj.BulkJob.Object
+	fields := (*qj.SFObjectMeta)["fields"].([]interface{})
+	for i, fieldName := range qj.QueryData[0] {
+		f := getField(fieldName, fields)
+		if f == nil || strings.EqualFold(fieldName, "Id") {
+			continue
+		}
+		sfType, _ := f["type"].(string)
+		policy := mask.DefaultPolicy(fieldName, sfType)
+		if p := cfg.Mask.PolicyFor(obj, fieldName); p != "" {
+			if policy, err = mask.ParsePolicy(p); err != nil {
+				return fmt.Errorf("%v.%v : %v", obj, fieldName, err)
+			}
+		}
+		if policy == mask.Keep {
+			continue
+		}
+		if f["updateable"] != true {
+			log.Printf("%v.%v can't be updated, it has been left as it is", obj, fieldName)
+			continue
+		}
+		rows := qj.QueryData[1:]
+		switch policy {
+		case mask.Null:
+			for _, row := range rows {
+				row[i] = mask.NullValue
+			}
+		case mask.Shuffle:
+			col := make([]string, len(rows))
+			for r, row := range rows {
+				col[r] = row[i]
+			}
+			m.Shuffle(obj+"."+fieldName, col)
+			for r, row := range rows {
+				row[i] = col[r]
+			}
+		case mask.Mask:
+			kind := mask.KindOf(obj, fieldName, sfType)
+			length, _ := f["length"].(float64)
+			for _, row := range rows {
+				row[i] = m.Value(kind, row[i])
+				// a fake name or email can be longer than the one it replaced
+				if length > 0 && len([]rune(row[i])) > int(length) {
+					row[i] = string([]rune(row[i])[:int(length)])
+				}
+			}
+		}
+		log.Printf("%v %v.%v", policy, obj, fieldName)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sforce
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+)
+
+const personAccountQuery string = "select Id, PersonContactId from Account where IsPersonAccount = true"
+
+// a person account and the contact record that sits behind it
+type PersonAccount struct {
+	Id              string
+	PersonContactId string
+}
+
+// returns true if person accounts are enabled in the org.
+// The Account describe only has an IsPersonAccount field when they are.
+func HasPersonAccounts(account *simpleforce.SObjectMeta) bool {
+	if account == nil {
+		return false
+	}
+	return getField("IsPersonAccount", (*account)["fields"].([]interface{})) != nil
+}
+
+// returns the Id of the active person account record type
+func GetPersonAccountRecordTypeId(c *simpleforce.Client) (string, error) {
+	qr, err := c.Query("select Id, Name from RecordType where SobjectType = 'Account' and IsPersonType = true and IsActive = true order by CreatedDate")
+	if err != nil {
+		return "", err
+	}
+	if len(qr.Records) == 0 {
+		return "", fmt.Errorf("person accounts are enabled but there is no active person account record type")
+	}
+	rt := qr.Records[0]
+	if len(qr.Records) > 1 {
+		log.Printf("Found %d person account record types, using %v", len(qr.Records), rt.StringField("Name"))
+	}
+	return rt.ID(), nil
+}
+
+// returns every person account with its PersonContactId, cached in objIds
+func GetPersonAccounts(cfg *config.Config, c *simpleforce.Client, objIds *sync.Map) ([]PersonAccount, error) {
+	if i, ok := objIds.Load(personAccountQuery); ok {
+		return i.([]PersonAccount), nil
+	}
+	rows, err := GetRowsForQuery(cfg, personAccountQuery, c)
+	if err != nil {
+		return nil, err
+	}
+	var pas []PersonAccount
+	for _, r := range rows {
+		if len(r) != 2 {
+			return nil, fmt.Errorf("expected Id and PersonContactId from [%v]", personAccountQuery)
+		}
+		pas = append(pas, PersonAccount{Id: r[0], PersonContactId: r[1]})
+	}
+	if len(pas) == 0 {
+		return nil, fmt.Errorf("there are no person accounts in the org to relate records to")
+	}
+	// in the same order whatever order the org returns them, for seeded runs
+	sort.Slice(pas, func(i, j int) bool { return pas[i].Id < pas[j].Id })
+	objIds.Store(personAccountQuery, pas)
+	return pas, nil
+}
@@ -0,0 +1,63 @@
+package sforce
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+)
+
+// returned (wrapped) when a lookup has nothing to point at
+var ErrNoCandidates = errors.New("no candidate records")
+
+// returns the Ids that the field on sobj can be populated with.
+//
+// LOOKUP_FILTERS can restrict the candidates for sobj.field with a where clause,
+// or point at a saved query in LOOKUP_POOLS using @name. Unfiltered Ids are cached in objIds
+// under the referenced object name, filtered pools are cached under their query.
+// It is an error for a pool to have no candidates.
+func GetCandidateIds(cfg *config.Config, c *simpleforce.Client, objIds *sync.Map, sobj string, field string, referenceTo string) ([]string, error) {
+
+	q, err := candidateQuery(cfg, sobj, field, referenceTo)
+	if err != nil {
+		return nil, err
+	}
+	key := referenceTo
+	if q != allIdsQuery(referenceTo) {
+		key = q
+	}
+	if i, ok := objIds.Load(key); ok {
+		return i.([]string), nil
+	}
+	ids, err := GetIdsForQuery(cfg, q, c)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w to populate %v.%v with [%v]", ErrNoCandidates, sobj, field, q)
+	}
+	// the org returns them in any order, sorted a seeded run picks the same records each time
+	sort.Strings(ids)
+	objIds.Store(key, ids)
+	return ids, nil
+}
+
+// builds the query used to fetch candidate Ids for sobj.field
+func candidateQuery(cfg *config.Config, sobj string, field string, referenceTo string) (string, error) {
+	filter := cfg.Lookups.FilterFor(sobj, field)
+	switch {
+	case strings.HasPrefix(filter, "@"):
+		q := cfg.Lookups.PoolQuery(filter[1:])
+		if q == "" {
+			return "", fmt.Errorf("%v.%v uses pool %v which is not defined in LOOKUP_POOLS", sobj, field, filter[1:])
+		}
+		return q, nil
+	case filter != "":
+		return fmt.Sprintf("select id from %v where %v", referenceTo, filter), nil
+	}
+	return allIdsQuery(referenceTo), nil
+}
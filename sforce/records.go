@@ -0,0 +1,94 @@
+package sforce
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simpleforce/simpleforce"
+)
+
+// how many Ids go into each "where Id in (...)" query
+const recordsPerQuery int = 200
+
+// returns the values of fields for the records with the given Ids, keyed by Id then field name.
+// Uses the REST api in chunks so it is only worth using for the records we have actually picked.
+func GetRecords(c *simpleforce.Client, obj string, ids []string, fields []string) (map[string]map[string]string, error) {
+	records := make(map[string]map[string]string)
+	seen := make(map[string]bool)
+	var unique []string
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	for start := 0; start < len(unique); start += recordsPerQuery {
+		end := start + recordsPerQuery
+		if end > len(unique) {
+			end = len(unique)
+		}
+		q := fmt.Sprintf("select %v from %v where Id in ('%v')", strings.Join(selectFields(fields), ", "), obj, strings.Join(unique[start:end], "','"))
+		qr, err := c.Query(q)
+		for {
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range qr.Records {
+				values := make(map[string]string)
+				for _, f := range fields {
+					if strings.EqualFold(f, "Id") {
+						values[f] = r.ID()
+						continue
+					}
+					if v := fieldOf(r, f); v != nil {
+						values[f] = fmt.Sprintf("%v", v)
+					}
+				}
+				records[r.ID()] = values
+			}
+			if qr.Done || qr.NextRecordsURL == "" {
+				break
+			}
+			qr, err = c.Query(qr.NextRecordsURL)
+		}
+	}
+	return records, nil
+}
+
+// the value of the field, whatever the case it was asked for in
+func fieldOf(r simpleforce.SObject, name string) interface{} {
+	if v := r.InterfaceField(name); v != nil {
+		return v
+	}
+	for k, v := range r {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
+
+// returns Id then the fields, each once, soql won't select a field twice
+func selectFields(fields []string) []string {
+	selected := []string{"Id"}
+	seen := map[string]bool{"id": true}
+	for _, f := range fields {
+		if k := strings.ToLower(strings.TrimSpace(f)); !seen[k] {
+			seen[k] = true
+			selected = append(selected, strings.TrimSpace(f))
+		}
+	}
+	return selected
+}
+
+// returns the Id of the org the client is logged in to
+func OrgId(c *simpleforce.Client) (string, error) {
+	qr, err := c.Query("select Id from Organization")
+	if err != nil {
+		return "", err
+	}
+	if len(qr.Records) == 0 {
+		return "", fmt.Errorf("unable to query the Id of the org")
+	}
+	return qr.Records[0].ID(), nil
+}
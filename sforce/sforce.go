@@ -4,22 +4,25 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
-	"math"
-	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/simpleforce/simpleforce"
 	"github.com/troysellers/go-modifier/config"
 	"github.com/troysellers/go-modifier/file"
 	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/mask"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/rules"
+	"github.com/troysellers/go-modifier/staging"
+	"github.com/troysellers/go-modifier/temporal"
 	"github.com/tzmfreedom/go-soapforce"
 )
 
@@ -81,7 +84,7 @@ type UpsertJob struct {
 // creates the bulk ingest job
 type BulkUpsertJobCreate struct {
 	Object              string `json:"object"`
-	ExternalIdFieldName string `json:"externalIdFieldName"`
+	ExternalIdFieldName string `json:"externalIdFieldName,omitempty"`
 	ContentType         string `json:"contentType"`
 	Operation           string `json:"operation"`
 }
@@ -187,32 +190,73 @@ func (qj *QueryJob) ModifyData(cfg *config.Config, objIds *sync.Map, c *simplefo
 
 	log.Println("\n\nwe are trying to modify things")
 
+	rs, err := rules.Get(cfg)
+	if err != nil {
+		return err
+	}
+	// the names and addresses of a row come from one locale
+	locs := rowLocales(qj.QueryData)
 	// for each header (field name)
 	for i, fieldName := range qj.QueryData[0] {
 		// get the SF metadata for this field
 		f := getField(fieldName, (*qj.SFObjectMeta)["fields"].([]interface{}))
+		// a rule can leave the field alone, or say how its values are made
+		rule, _ := rs.For(qj.BulkJob.Object, fieldName)
+		if rule.Skip {
+			log.Printf("leaving %v as it is", fieldName)
+			continue
+		}
 		// if field is updateable
 
-		if f["updateable"].(bool) {
+		if f["updateable"].(bool) && IsUniqueText(f) && !cfg.ModifyWithNull && !rule.Generates() {
+			// get all the unique values at once so the sequence is only saved once
+			vals, err := NextUniqueValues(cfg, c, qj.BulkJob.Object, f, len(qj.QueryData)-1)
+			if err != nil {
+				return err
+			}
+			for r, row := range qj.QueryData[1:] {
+				row[i] = vals[r]
+			}
+		} else if f["updateable"].(bool) {
+			gen, err := rule.Native(f)
+			if err != nil {
+				return fmt.Errorf("the rule for %v.%v : %v", qj.BulkJob.Object, fieldName, err)
+			}
 			// loop through each row in the file
-			for _, row := range qj.QueryData[1:] {
-
-				val, err := GetValueForType(cfg, f, qj.SFClient, objIds)
+			for r, row := range qj.QueryData[1:] {
+				if cfg.ModifyWithNull || rule.Blank() {
+					row[i] = mask.NullValue
+					continue
+				}
+				var val interface{}
+				if gen != nil {
+					val, err = gen()
+				} else if v, ok := localeValue(qj.BulkJob.Object, f, locs[r]); ok {
+					val, err = v, nil
+				} else {
+					val, err = GetValueForType(cfg, qj.BulkJob.Object, f, qj.SFClient, objIds)
+				}
 				if err != nil {
 					log.Printf("%v", err)
 				} else {
-					if cfg.ModifyWithNull {
-						row[i] = "null"
-					} else {
-						// update the column with this random value
-						row[i] = fmt.Sprintf("%v", val)
-					}
+					// update the column with this random value
+					row[i] = fmt.Sprintf("%v", val)
 					log.Printf("update %v to %v", fieldName, row[i])
 				}
 			}
 		}
 
 	}
+	// make the modified dates consistent with each other
+	constraints, err := temporal.ForObject(qj.BulkJob.Object, cfg.Dates)
+	if err != nil {
+		return err
+	}
+	var rejected int
+	qj.QueryData, rejected = temporal.Apply(qj.QueryData, constraints, temporal.FieldTypes((*qj.SFObjectMeta)["fields"].([]interface{})))
+	if rejected > 0 {
+		log.Printf("Rejected %d %v rows that didn't satisfy the date constraints", rejected, qj.BulkJob.Object)
+	}
 	return nil
 }
 
@@ -241,6 +285,9 @@ func GetBulkQuery(cfg *config.Config, c *simpleforce.Client, q string) (QueryJob
 		if queryJob.BulkJob.State == "JobComplete" {
 			break
 		}
+		if queryJob.BulkJob.State == "Failed" || queryJob.BulkJob.State == "Aborted" {
+			return *queryJob, fmt.Errorf("bulk query %v %v", queryJob.BulkJob.Id, strings.ToLower(queryJob.BulkJob.State))
+		}
 		time.Sleep(10 * time.Second)
 		if err := queryJob.getBulkJobState(); err != nil {
 			return *queryJob, err
@@ -262,46 +309,83 @@ func GetBulkQuery(cfg *config.Config, c *simpleforce.Client, q string) (QueryJob
 		return *queryJob, err
 	}
 	queryJob.FilePath = d
+	staging.Record(cfg, staging.Query, queryJob.BulkJob.Object, queryJob.BulkJob.Id, queryJob.QueryData)
 
 	return *queryJob, nil
 }
 
+// upserts the CSV on Id, rows without an Id are inserted.
+// CSVs that need a mapping (.json or Data Loader .sdl) go through MapCSV first, as the load command does
 func UploadCSVToSalesforce(cfg *config.Config, c *simpleforce.Client, csvfile string, obj string) error {
+	return IngestCSV(cfg, c, csvfile, obj, "upsert", "Id")
+}
+
+// deletes the records whose Ids are in the CSV
+func DeleteCSVFromSalesforce(cfg *config.Config, c *simpleforce.Client, csvfile string, obj string) error {
+	return IngestCSV(cfg, c, csvfile, obj, "delete", "")
+}
 
-	// update the object if we are loading personaccounts
-	if strings.EqualFold(obj, "personaccount") {
-		obj = "account"
+// runs a Bulk V2 ingest job of the given operation (insert, update, upsert, delete or hardDelete) for the CSV.
+// externalId is the field upserts match on, Id if it is blank.
+// Returns a *FailedRecordsError if the job completes with failed records.
+func IngestCSV(cfg *config.Config, c *simpleforce.Client, csvfile string, obj string, operation string, externalId string) error {
+	_, err := RunIngestJob(cfg, c, csvfile, obj, operation, externalId)
+	return err
+}
+
+// runs the ingest job as IngestCSV does and returns it so the results can be fetched.
+// The job is returned along with a *FailedRecordsError when some of the records failed.
+func RunIngestJob(cfg *config.Config, c *simpleforce.Client, csvfile string, obj string, operation string, externalId string) (*UpsertJob, error) {
+
+	// check the file against the describe before we create any jobs, there is nothing to check for deletes
+	if operation != "delete" && operation != "hardDelete" {
+		if err := validateUpload(cfg, c, csvfile, obj); err != nil {
+			return nil, err
+		}
 	}
 
-	// create the bulk update job
-	uj := UpsertJob{
+	// create the bulk job
+	uj := &UpsertJob{
 		SessionId:    c.GetSid(),
 		SFEndpoint:   c.GetLoc(),
 		ApiVersion:   cfg.SF.ApiVersion,
 		ModifiedFile: csvfile,
 		Cfg:          cfg,
 		Create: BulkUpsertJobCreate{
-			Object:              obj,
-			ExternalIdFieldName: "Id",
-			ContentType:         "CSV",
-			Operation:           "upsert",
+			Object:      obj,
+			ContentType: "CSV",
+			Operation:   operation,
 		},
 	}
+	if operation == "upsert" {
+		uj.Create.ExternalIdFieldName = externalId
+		if externalId == "" {
+			uj.Create.ExternalIdFieldName = "Id"
+		}
+	}
 
 	if err := uj.createBulkIngest(); err != nil {
-		return err
+		return nil, err
 	}
+	staging.RecordFile(cfg, staging.Upload, obj, uj.Job.Id, csvfile)
 	if err := uj.sendData(); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := uj.closeJob(); err != nil {
-		return err
+		return nil, err
 	}
-	if err := uj.GetJobStatus(); err != nil {
-		return err
+	err := uj.GetJobStatus()
+	var failed *FailedRecordsError
+	if err != nil && !errors.As(err, &failed) {
+		return nil, err
 	}
-	return nil
+	if cfg.Staging.Path != "" && uj.Job.NumberRecordsProcessed > uj.Job.NumberRecordsFailed {
+		if serr := uj.stageSuccessfulRecords(); serr != nil {
+			log.Printf("Unable to stage the results of job %v : %v", uj.Job.Id, serr)
+		}
+	}
+	return uj, err
 }
 
 // creates the BulkV2 Query
@@ -349,7 +433,7 @@ func (uj *UpsertJob) createBulkIngest() error {
 	}
 	var upsertJob BulkUpsertJob
 	if err := json.Unmarshal(responseBytes, &upsertJob); err != nil {
-		panic(err)
+		return err
 	}
 	uj.Job = upsertJob
 	return nil
@@ -436,6 +520,9 @@ func getCSVBytes(file string) ([]byte, error) {
 
 	return f, nil
 }
+
+// waits for the ingest job to finish. Failed records are written to <object>-unsuccessful.csv
+// and returned as a *FailedRecordsError.
 func (uj *UpsertJob) GetJobStatus() error {
 	h := make(map[string]string)
 	h["Content-Type"] = "application/json; charset=UTF-8"
@@ -454,24 +541,33 @@ func (uj *UpsertJob) GetJobStatus() error {
 		if uj.Job.State == "JobComplete" {
 			break
 		}
+		if uj.Job.State == "Failed" || uj.Job.State == "Aborted" {
+			return fmt.Errorf("bulk job %v [%v on %v] %v", uj.Job.Id, uj.Job.Operation, uj.Job.Object, strings.ToLower(uj.Job.State))
+		}
 		log.Println("Waiting 30 seconds before trying again")
 		time.Sleep(30 * time.Second) // wait 30 secionds
 	}
-	if uj.Job.NumberRecordsFailed > 0 {
-		uj.fetchFailedRecords()
-	}
 	log.Printf("Job %s [%s on %s] complete in %dms\n", uj.Job.Id, uj.Job.Operation, uj.Job.Object, uj.Job.TotalProcessingTime)
 	log.Printf("Total Records %d\n", uj.Job.NumberRecordsProcessed)
 	log.Printf("Records Failed %d\n", uj.Job.NumberRecordsFailed)
 	if uj.Job.NumberRecordsFailed > 0 {
-		if err := uj.fetchFailedRecords(); err != nil {
+		fPath, err := uj.fetchFailedRecords()
+		if err != nil {
 			return err
 		}
+		return &FailedRecordsError{
+			Object:    uj.Job.Object,
+			Operation: uj.Job.Operation,
+			Processed: uj.Job.NumberRecordsProcessed,
+			Failed:    uj.Job.NumberRecordsFailed,
+			File:      fPath,
+		}
 	}
 	return nil
 }
 
-func (uj *UpsertJob) fetchFailedRecords() error {
+// writes the failed records of the job to file and returns the path
+func (uj *UpsertJob) fetchFailedRecords() (string, error) {
 	h := make(map[string]string)
 	h["Content-Type"] = "application/json; charset=UTF-8"
 	h["Accept"] = "application/json"
@@ -480,21 +576,58 @@ func (uj *UpsertJob) fetchFailedRecords() error {
 	url := fmt.Sprintf("%v%v%v/failedResults/", uj.SFEndpoint, fmt.Sprintf(bulkIngestEndpoint, uj.ApiVersion), uj.Job.Id)
 	_, responseBytes, err := doHttp(url, uj.SessionId, nil, "GET", h)
 	if err != nil {
-		return err
+		return "", err
 	}
 	lines, err := csv.NewReader(bytes.NewBuffer(responseBytes)).ReadAll()
+	if err != nil {
+		return "", err
+	}
+	fPath, err := file.BuildFilePath(fmt.Sprintf("%v-unsuccessful.csv", uj.Job.Object), uj.Cfg)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteCsv(fPath, lines); err != nil {
+		return "", err
+	}
+	staging.Record(uj.Cfg, staging.Failed, uj.Job.Object, uj.Job.Id, lines)
+	return fPath, nil
+}
+
+// returns the successful results of the job, sf__Id and sf__Created then the columns as they were sent
+func (uj *UpsertJob) SuccessfulResults() ([][]string, error) {
+	h := make(map[string]string)
+	h["Content-Type"] = "application/json; charset=UTF-8"
+	h["Accept"] = "application/json"
+	h["Authorization"] = fmt.Sprintf("Bearer %v", uj.SessionId)
+
+	url := fmt.Sprintf("%v%v%v/successfulResults/", uj.SFEndpoint, fmt.Sprintf(bulkIngestEndpoint, uj.ApiVersion), uj.Job.Id)
+	_, responseBytes, err := doHttp(url, uj.SessionId, nil, "GET", h)
+	if err != nil {
+		return nil, err
+	}
+	return csv.NewReader(bytes.NewBuffer(responseBytes)).ReadAll()
+}
+
+// saves the successful results to the staging store along with the Id each uploaded row was given
+func (uj *UpsertJob) stageSuccessfulRecords() error {
+	lines, err := uj.SuccessfulResults()
 	if err != nil {
 		return err
 	}
-	fPath, err := file.BuildFilePath("unsuccessful.csv", uj.Cfg)
+	s, err := staging.Get(uj.Cfg)
 	if err != nil {
 		return err
 	}
-	if _, err := file.WriteCsv(fPath, lines); err != nil {
+	if err := s.Save(staging.Success, uj.Job.Object, uj.Job.Id, lines); err != nil {
 		return err
 	}
-	return nil
+	uploaded, err := file.ReadCsv(uj.ModifiedFile)
+	if err != nil {
+		return err
+	}
+	return s.SaveIds(uj.Job.Object, uj.Job.Id, staging.MatchIds(uploaded, lines))
 }
+
 func (uj *UpsertJob) closeJob() error {
 	h := make(map[string]string)
 	h["Content-Type"] = "application/json; charset=UTF-8"
@@ -554,99 +687,110 @@ func doHttp(url string, sid string, body []byte, method string, headers map[stri
 	return res.Header, bytes, nil
 }
 
-func GetValueForType(cfg *config.Config, f map[string]interface{}, c *simpleforce.Client, objIds *sync.Map) (interface{}, error) {
+func GetValueForType(cfg *config.Config, sobj string, f map[string]interface{}, c *simpleforce.Client, objIds *sync.Map) (interface{}, error) {
 
 	/* if can be empty, retun empty on a 10%
-	if f["nillable"].(bool) && rand.Intn(10) < 2 {
+	if f["nillable"].(bool) && random.Intn(10) < 2 {
 		return nil, nil
 	}*/
 	switch f["type"].(string) {
 	case "id":
 		return nil, fmt.Errorf("id values are not supported for generation")
 	case "boolean":
-		return rand.Intn(10) >= 5, nil
+		return random.Intn(10) >= 5, nil
 	case "string", "encryptedstring":
-		if f["unique"].(bool) {
-			return uuid.New(), nil
+		if IsUniqueText(f) {
+			vals, err := NextUniqueValues(cfg, c, sobj, f, 1)
+			if err != nil {
+				return nil, err
+			}
+			return vals[0], nil
+		}
+		// the subject of a case reads like one
+		if strings.EqualFold(sobj, "case") && f["name"] == "Subject" {
+			return truncate(lorem.TextSentence(), fieldLength(f)), nil
 		}
-		l := int(f["length"].(float64))
-		return lorem.Word(1, rand.Intn(l)), nil
+		return stringValue(f), nil
 	case "datetime", "date":
-		d := time.Now()
-		d = d.AddDate(0, rand.Intn(12), rand.Intn(30))
-		return d, nil
+		// somewhere in the last year, the date constraints move it relative to other dates
+		d := random.Now().UTC()
+		d = d.AddDate(0, -random.Intn(12), -random.Intn(30))
+		if f["type"].(string) == "date" {
+			return d.Format(temporal.DateFormat), nil
+		}
+		return d.Format(temporal.DateTimeFormat), nil
+	case "time":
+		return timeValue(), nil
 	case "reference":
 		//	log.Printf("REFERENCCE %v\n", f)
 		// get the name of the object this field references
 		rt := f["referenceTo"].([]interface{})
 		referenceTo := rt[0].(string)
 		log.Printf("reference to [%v]\n", referenceTo)
-		// have we already got the complete list of ids?
-		_, ok := objIds.Load(referenceTo)
-		if !ok {
-			// if not, get and cache in the sync.Map
-			ids, err := GetAllObjIds(cfg, referenceTo, c)
-			if err != nil {
-				return nil, err
-			}
-			objIds.Store(referenceTo, ids)
+		// fetches (or loads from the cache) the Ids this field can be populated with
+		ids, err := GetCandidateIds(cfg, c, objIds, sobj, f["name"].(string), referenceTo)
+		if err != nil {
+			return nil, err
 		}
-		i, _ := objIds.Load(referenceTo)
-		ids := i.([]string)
-		return ids[rand.Intn(len(ids))], nil
-	case "currency", "double":
-		p := f["precision"].(float64)
-		s := f["scale"].(float64)
-		return rand.Intn(int(p)) / int(math.Pow10(int(s))), nil
-		//return rand.Intn(12000) * 100, nil
+		return ids[random.Intn(len(ids))], nil
+	case "currency", "double", "percent":
+		return numberValue(f), nil
+	case "int", "long":
+		return intValue(f), nil
 	case "email":
-		return lorem.Email(), nil
-	case "location":
-		return nil, fmt.Errorf("location value not implemented yet")
-	case "percent":
-		return float32(rand.Intn(100)), nil
-	case "int":
-		d := int(f["digits"].(float64))
-		return rand.Intn(int(math.Pow10(d))), nil
+		return truncate(lorem.Email(), fieldLength(f)), nil
 	case "phone":
-		return "000000000", nil
-		//return nil, fmt.Errorf("phone value not implemented yet")
-	case "picklist", "multipicklist":
-		/*
-			if f["name"].(string) == "StageName" {
-				return "Closed Lost", nil
-			}*/
-		plv := f["picklistValues"].([]interface{})
-		selected := plv[rand.Intn(len(plv))]
-		return selected.(map[string]interface{})["value"], nil
+		return phoneValue(f), nil
+	case "picklist", "multipicklist", "combobox":
+		return picklistValue(f)
 	case "textarea":
-		/*
-			l := int(f["length"].(float64))
-			s := lorem.Sentence(1, l)
-			if len(s) < int(l) {
-				return s, nil
-			} else {
-				return s[:l], nil
-			} */
-		return "broken", nil
-
-	case "time":
-		return nil, fmt.Errorf("phone value not implemented yet")
+		return textAreaValue(f), nil
 	case "url":
-		return lorem.Url(), nil
+		return truncate(lorem.Url(), fieldLength(f)), nil
+	case "base64":
+		return base64Value(), nil
+	case "location", "address":
+		// compound fields can't be set, their parts (BillingStreet, Where__Latitude__s..) are generated instead
+		return nil, fmt.Errorf("%v is a compound %v field, its component fields are generated instead", f["name"], f["type"])
 	}
 
-	return nil, nil
+	return nil, fmt.Errorf("%v fields (%v) are not supported for generation", f["type"], f["name"])
 }
 
 // returns object, allIds and an error
 func GetAllObjIds(cfg *config.Config, obj string, c *simpleforce.Client) ([]string, error) {
+	return GetIdsForQuery(cfg, allIdsQuery(obj), c)
+}
 
+func allIdsQuery(obj string) string {
 	q := fmt.Sprintf("select id from %v", obj)
 
 	if strings.EqualFold(obj, "user") {
 		q += " where isActive = true and userType = 'standard'"
 	}
+	return q
+}
+
+// runs a bulk query that selects a single Id column and returns the values
+func GetIdsForQuery(cfg *config.Config, q string, c *simpleforce.Client) ([]string, error) {
+
+	rows, err := GetRowsForQuery(cfg, q, c)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, r := range rows {
+		if len(r) > 1 {
+			return nil, fmt.Errorf("there has been an error downloading IDs. More than one value per record returned")
+		}
+		results = append(results, r[0])
+	}
+	return results, nil
+}
+
+// runs a bulk query and returns the result rows without the header
+func GetRowsForQuery(cfg *config.Config, q string, c *simpleforce.Client) ([][]string, error) {
+
 	log.Printf("Downloading all IDS [%v]. This could take a while... ", q)
 	qj, err := GetBulkQuery(cfg, c, q)
 	if err != nil {
@@ -661,14 +805,9 @@ func GetAllObjIds(cfg *config.Config, obj string, c *simpleforce.Client) ([]stri
 	if err != nil {
 		return nil, err
 	}
-
-	var results []string
-	for _, r := range rows[1:] { // ignore the header row
-		if len(r) > 1 {
-			return nil, fmt.Errorf("there has been an error downloading IDs. More than one value per record returned")
-		}
-		results = append(results, r[0])
+	if len(rows) == 0 {
+		return nil, nil
 	}
-	log.Printf("Found %d id values for %v", len(results), obj)
-	return results, nil
+	log.Printf("Found %d id values for %v", len(rows)-1, qj.BulkJob.Object)
+	return rows[1:], nil // ignore the header row
 }
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/simpleforce/simpleforce"
 	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/lorem"
 	"github.com/tzmfreedom/go-soapforce"
 )
 
@@ -167,3 +169,221 @@ func TestCreateAccount(t *testing.T) {
 		}
 	}
 }
+
+func TestCandidateQuery(t *testing.T) {
+	cfg := &config.Config{
+		Lookups: config.LookupConfig{
+			Filters: map[string]string{
+				"Case.AccountId":    "Active__c = 'Yes'",
+				"contact.accountid": "@customers",
+				"Task.WhatId":       "@missing",
+			},
+			Pools: map[string]string{
+				"Customers": "select id from account where type = 'Customer'",
+			},
+		},
+	}
+	q, err := candidateQuery(cfg, "Case", "AccountId", "Account")
+	if err != nil || q != "select id from Account where Active__c = 'Yes'" {
+		t.Errorf("unexpected filter query [%v] %v", q, err)
+	}
+	q, err = candidateQuery(cfg, "Contact", "AccountId", "Account")
+	if err != nil || q != "select id from account where type = 'Customer'" {
+		t.Errorf("unexpected pool query [%v] %v", q, err)
+	}
+	if _, err := candidateQuery(cfg, "Task", "WhatId", "Account"); err == nil {
+		t.Error("expected an error for an undefined pool")
+	}
+	q, _ = candidateQuery(cfg, "Case", "OwnerId", "User")
+	if q != allIdsQuery("User") {
+		t.Errorf("unexpected default query [%v]", q)
+	}
+}
+
+// the describe metadata of a field, extra overrides the defaults
+func testField(name string, typ string, extra map[string]interface{}) map[string]interface{} {
+	f := map[string]interface{}{
+		"name": name, "type": typ, "createable": true, "updateable": true, "nillable": true,
+		"defaultedOnCreate": false, "length": float64(0), "precision": float64(0), "scale": float64(0), "digits": float64(0),
+	}
+	for k, v := range extra {
+		f[k] = v
+	}
+	return f
+}
+
+func TestValidateCSV(t *testing.T) {
+	meta := &simpleforce.SObjectMeta{
+		"name": "Account",
+		"fields": []interface{}{
+			testField("Id", "id", map[string]interface{}{"createable": false, "updateable": false, "nillable": false, "defaultedOnCreate": true}),
+			testField("Name", "string", map[string]interface{}{"length": float64(10), "nillable": false}),
+			testField("Rating", "picklist", map[string]interface{}{"length": float64(40), "restrictedPicklist": true,
+				"picklistValues": []interface{}{map[string]interface{}{"value": "Hot", "active": true}}}),
+			testField("AnnualRevenue", "currency", map[string]interface{}{"precision": float64(5), "scale": float64(2)}),
+			testField("SLAExpirationDate__c", "date", nil),
+			testField("ParentId", "reference", map[string]interface{}{"relationshipName": "Parent"}),
+			testField("CreatedDate", "datetime", map[string]interface{}{"createable": false, "updateable": false}),
+		},
+	}
+	data := [][]string{
+		{"Id", "Name", "Rating", "AnnualRevenue", "SLAExpirationDate__c", "ParentId", "CreatedDate", "Bogus__c", "Parent.Ext_Id__c"},
+		{"", "Acme", "Hot", "123.45", "2024-01-31", "001000000000001AAA", "", "x", "1"},
+		{"", "", "Cold", "1234.5", "31/01/2024", "001xyz", "2024-01-01T00:00:00.000Z", "x", "1"},
+		{"001000000000001AAA", "A name that is too long", "Hot", "1.234", "", "", "", "x", "1"},
+	}
+	report := ValidateCSV(meta, data)
+	log.Print(report)
+	want := map[string]bool{
+		"Bogus__c unknown column":                                   true,
+		"Name required":                                             true,
+		"Rating not a value of the restricted picklist":             true,
+		"AnnualRevenue more than 3 digits before the decimal point": true,
+		"SLAExpirationDate__c not a date (YYYY-MM-DD)":              true,
+		"ParentId malformed Id":                                     true,
+		"CreatedDate not createable":                                true,
+		"Name longer than 10 characters":                            true,
+		"AnnualRevenue more than 2 decimal places":                  true,
+	}
+	got := make(map[string]bool)
+	for _, i := range report.Issues {
+		got[i.Column+" "+i.Problem] = true
+	}
+	for w := range want {
+		if !got[w] {
+			t.Errorf("missing issue %v", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("expected %d distinct issues, got %v", len(want), got)
+	}
+}
+
+func TestValidId(t *testing.T) {
+	if !validId("0016g00000IGY2xAAH") || !validId("0016g00000IGY2x") {
+		t.Error("valid Ids were rejected")
+	}
+	if validId("0016g00000IGY2xAAA") || validId("0016g00000IGY2") {
+		t.Error("invalid Ids were accepted")
+	}
+}
+
+func TestGetValueForType(t *testing.T) {
+	values := []interface{}{
+		map[string]interface{}{"value": "A", "active": true},
+		map[string]interface{}{"value": "B", "active": true},
+		map[string]interface{}{"value": "C", "active": true},
+		map[string]interface{}{"value": "Old", "active": false},
+	}
+	fields := []map[string]interface{}{
+		testField("Name", "string", map[string]interface{}{"length": float64(5)}),
+		testField("AnnualRevenue", "currency", map[string]interface{}{"precision": float64(5), "scale": float64(2)}),
+		testField("Score__c", "double", map[string]interface{}{"precision": float64(3), "scale": float64(3)}),
+		testField("Discount__c", "percent", map[string]interface{}{"precision": float64(5), "scale": float64(2)}),
+		testField("Where__Latitude__s", "double", map[string]interface{}{"precision": float64(9), "scale": float64(6)}),
+		testField("Where__Longitude__s", "double", map[string]interface{}{"precision": float64(9), "scale": float64(6)}),
+		testField("NumberOfEmployees", "int", map[string]interface{}{"digits": float64(3)}),
+		testField("Big__c", "long", map[string]interface{}{"digits": float64(18)}),
+		testField("Phone", "phone", map[string]interface{}{"length": float64(40)}),
+		testField("Email", "email", map[string]interface{}{"length": float64(20)}),
+		testField("Website", "url", map[string]interface{}{"length": float64(255)}),
+		testField("Description", "textarea", map[string]interface{}{"length": float64(32000)}),
+		testField("Notes__c", "textarea", map[string]interface{}{"length": float64(100), "extraTypeInfo": "plaintextarea"}),
+		testField("Rating", "picklist", map[string]interface{}{"length": float64(40), "restrictedPicklist": true, "picklistValues": values}),
+		testField("Regions__c", "multipicklist", map[string]interface{}{"length": float64(4099), "restrictedPicklist": true, "picklistValues": values}),
+		testField("Kind__c", "combobox", map[string]interface{}{"length": float64(10), "picklistValues": values}),
+		testField("SLAExpirationDate__c", "date", nil),
+		testField("Start__c", "datetime", nil),
+		testField("IsActive__c", "boolean", nil),
+	}
+	for _, f := range fields {
+		multi := false
+		for i := 0; i < 200; i++ {
+			v, err := GetValueForType(nil, "Account", f, nil, nil)
+			if err != nil {
+				t.Fatalf("%v : %v", f["name"], err)
+			}
+			s := fmt.Sprintf("%v", v)
+			if problem := checkValue(f, s); problem != "" {
+				t.Errorf("%v generated %q which is %v", f["name"], s, problem)
+				break
+			}
+			multi = multi || strings.Contains(s, ";")
+			name := f["name"].(string)
+			if strings.Contains(name, "itude") {
+				var n float64
+				fmt.Sscanf(s, "%g", &n)
+				if limit := map[bool]float64{true: 90, false: 180}[strings.Contains(name, "Lat")]; n < -limit || n > limit {
+					t.Errorf("%v generated %v which is off the globe", name, n)
+				}
+			}
+		}
+		if f["type"] == "multipicklist" && !multi {
+			t.Errorf("%v never selected more than one value", f["name"])
+		}
+	}
+	for _, v := range []string{timeValue(), timeValue()} {
+		if _, err := time.Parse("15:04:05.000Z", v); err != nil {
+			t.Errorf("%v isn't a time : %v", v, err)
+		}
+	}
+	if _, err := GetValueForType(nil, "Account", testField("BillingAddress", "address", nil), nil, nil); err == nil {
+		t.Error("expected an error for a compound address")
+	}
+	if _, err := GetValueForType(nil, "Account", testField("Rating", "picklist", map[string]interface{}{"restrictedPicklist": true}), nil, nil); err == nil {
+		t.Error("expected an error for a restricted picklist without values")
+	}
+}
+
+func TestLocaleValue(t *testing.T) {
+	data := [][]string{
+		{"Id", "FirstName", "MailingCity", "MailingCountry"},
+		{"003000000000001", "", "", "Germany"},
+		{"003000000000002", "", "", "JP"},
+	}
+	locs := rowLocales(data)
+	if len(locs) != 2 || locs[0].Code != "de_DE" || locs[1].Code != "ja_JP" {
+		t.Fatalf("expected de_DE and ja_JP for the rows")
+	}
+	for _, name := range []string{"FirstName", "MailingCity", "MailingPostalCode", "MailingCountry", "Email"} {
+		typ := "string"
+		if name == "Email" {
+			typ = "email"
+		}
+		v, ok := localeValue("Contact", map[string]interface{}{"name": name, "type": typ, "length": float64(80)}, locs[1])
+		if !ok || v == "" {
+			t.Errorf("expected a value for %v", name)
+		}
+	}
+	if v, _ := localeValue("Contact", map[string]interface{}{"name": "MailingCountry", "type": "string", "length": float64(80)}, locs[0]); v != "Germany" {
+		t.Errorf("expected Germany, got %v", v)
+	}
+	lorem.SetPhoneFormat(lorem.PhoneE164)
+	defer lorem.SetPhoneFormat("")
+	if v, _ := localeValue("Contact", map[string]interface{}{"name": "MobilePhone", "type": "phone", "length": float64(40)}, locs[0]); !strings.HasPrefix(v, "+49") {
+		t.Errorf("expected a german number, got %v", v)
+	}
+	if _, ok := localeValue("Account", map[string]interface{}{"name": "Site", "type": "string", "length": float64(80)}, locs[0]); ok {
+		t.Errorf("Site isn't a name or an address")
+	}
+	if v, _ := localeValue("Account", map[string]interface{}{"name": "BillingCity", "type": "string", "length": float64(3)}, locs[0]); len([]rune(v)) > 3 {
+		t.Errorf("%v is longer than the field", v)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	got := strings.Join(selectFields([]string{"Website", "Id", "BillingCity", "website", "Name"}), ", ")
+	if got != "Id, Website, BillingCity, Name" {
+		t.Errorf("unexpected fields %v", got)
+	}
+}
+
+func TestFieldOf(t *testing.T) {
+	r := simpleforce.SObject{"Id": "001", "BillingCity": "Leeds"}
+	if v := fieldOf(r, "billingcity"); v != "Leeds" {
+		t.Errorf("expected the field whatever its case, got %v", v)
+	}
+	if v := fieldOf(r, "Website"); v != nil {
+		t.Errorf("expected nil for a field that isn't there, got %v", v)
+	}
+}
@@ -0,0 +1,119 @@
+package sforce
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/random"
+	"github.com/troysellers/go-modifier/unique"
+)
+
+var sequences *unique.Sequences
+var sequencesMu sync.Mutex
+
+// the highest sequence number found in the org for each Object.Field we have checked
+var orgFloors sync.Map
+
+// returns n values for a unique (or external Id) field that fit its length and won't collide
+// with values from earlier runs. The pattern comes from UNIQUE_PATTERNS or is derived from the length.
+// With UNIQUE_CHECK_ORG the sequence also starts past the highest matching value in the org.
+func NextUniqueValues(cfg *config.Config, c *simpleforce.Client, obj string, f map[string]interface{}, n int) ([]string, error) {
+	name := f["name"].(string)
+	key := fmt.Sprintf("%v.%v", obj, name)
+	length := int(f["length"].(float64))
+
+	var pattern unique.Pattern
+	if p := cfg.Unique.PatternFor(obj, name); p != "" {
+		var err error
+		if pattern, err = unique.ParsePattern(p); err != nil {
+			return nil, err
+		}
+	} else {
+		pattern = unique.DefaultPattern(obj, length)
+	}
+	if length > 0 && pattern.Len() > length {
+		return nil, fmt.Errorf("unique pattern for %v makes values of %d characters, the field only holds %d", key, pattern.Len(), length)
+	}
+
+	seq, err := getSequences(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var floor int64
+	if cfg.Unique.CheckOrg {
+		if floor, err = orgFloor(c, obj, name, pattern); err != nil {
+			return nil, err
+		}
+	}
+	values := make([]string, n)
+	for i := range values {
+		values[i] = pattern.Format(seq.Next(key, floor))
+		if length > 0 && len(values[i]) > length {
+			return nil, fmt.Errorf("the sequence for %v has outgrown the field length (%v), use a wider pattern", key, values[i])
+		}
+	}
+	if err := seq.Save(); err != nil {
+		return nil, err
+	}
+	if random.Seeded() {
+		// starting again would collide with the records the last run created
+		log.Printf("%v isn't seeded, it carries on from the sequence in sequences.json", key)
+	}
+	return values, nil
+}
+
+// loads the sequences from the data directory the first time they are needed
+func getSequences(cfg *config.Config) (*unique.Sequences, error) {
+	sequencesMu.Lock()
+	defer sequencesMu.Unlock()
+	if sequences != nil {
+		return sequences, nil
+	}
+	path, err := file.BuildFilePath("sequences.json", cfg)
+	if err != nil {
+		return nil, err
+	}
+	if sequences, err = unique.OpenSequences(path); err != nil {
+		return nil, err
+	}
+	return sequences, nil
+}
+
+// returns the highest sequence number in use in the org for values that match the pattern.
+// Zero padded values sort in sequence order so we only need the largest one.
+func orgFloor(c *simpleforce.Client, obj string, field string, pattern unique.Pattern) (int64, error) {
+	key := fmt.Sprintf("%v.%v", obj, field)
+	if f, ok := orgFloors.Load(key); ok {
+		return f.(int64), nil
+	}
+	q := fmt.Sprintf("select %v from %v where %v like '%v%%' order by %v desc limit 1", field, obj, field, strings.ReplaceAll(pattern.Prefix, "'", "\\'"), field)
+	qr, err := c.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	var floor int64
+	if len(qr.Records) > 0 {
+		if n, ok := pattern.Parse(qr.Records[0].StringField(field)); ok {
+			floor = n
+		}
+	}
+	log.Printf("Highest %v in the org is at sequence %d", key, floor)
+	orgFloors.Store(key, floor)
+	return floor, nil
+}
+
+// returns true for text fields that need values that are unique
+func IsUniqueText(f map[string]interface{}) bool {
+	t := f["type"].(string)
+	if t != "string" && t != "encryptedstring" {
+		return false
+	}
+	u, _ := f["unique"].(bool)
+	e, _ := f["externalId"].(bool)
+	return u || e
+}
@@ -0,0 +1,349 @@
+package sforce
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simpleforce/simpleforce"
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+)
+
+// Bulk API 2.0 uses #N/A to set a field to null
+const bulkNull string = "#N/A"
+
+var idExpr = regexp.MustCompile(`^[a-zA-Z0-9]{15}([a-zA-Z0-9]{3})?$`)
+
+// a problem found with a value (or a column when Row is 0) in a CSV
+type ValidationIssue struct {
+	Row     int
+	Column  string
+	Problem string
+	Value   string
+}
+
+// the outcome of checking a CSV against the object describe
+type ValidationReport struct {
+	Object string
+	Rows   int
+	Issues []ValidationIssue
+}
+
+// returns true if nothing was found
+func (r *ValidationReport) Ok() bool {
+	return len(r.Issues) == 0
+}
+
+// summarises the issues by column and problem with a few example rows of each
+func (r *ValidationReport) String() string {
+	type key struct{ column, problem string }
+	counts := make(map[key][]ValidationIssue)
+	var keys []key
+	for _, i := range r.Issues {
+		k := key{i.Column, i.Problem}
+		if _, ok := counts[k]; !ok {
+			keys = append(keys, k)
+		}
+		counts[k] = append(counts[k], i)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].column < keys[j].column })
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Validated %d %v rows : %d issues\n", r.Rows, r.Object, len(r.Issues))
+	for _, k := range keys {
+		issues := counts[k]
+		fmt.Fprintf(&sb, "  %v : %v (%d)\n", k.column, k.problem, len(issues))
+		for n, i := range issues {
+			if n == 3 {
+				fmt.Fprintf(&sb, "      ...\n")
+				break
+			}
+			if i.Row > 0 {
+				fmt.Fprintf(&sb, "      row %d [%v]\n", i.Row, i.Value)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (r *ValidationReport) add(row int, col string, value string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Row: row, Column: col, Value: value, Problem: fmt.Sprintf(format, args...)})
+}
+
+// checks data (header row first) against the describe for the object before it is sent to Salesforce.
+// Rows with an Id are checked as updates, rows without as creates.
+func ValidateCSV(meta *simpleforce.SObjectMeta, data [][]string) *ValidationReport {
+	report := &ValidationReport{Object: fmt.Sprintf("%v", (*meta)["name"])}
+	if len(data) == 0 {
+		report.add(0, "", "", "no header row")
+		return report
+	}
+	report.Rows = len(data) - 1
+	fields := (*meta)["fields"].([]interface{})
+
+	// work out what each column is
+	header := data[0]
+	columns := make([]map[string]interface{}, len(header))
+	idCol := -1
+	for i, col := range header {
+		if strings.EqualFold(col, "Id") {
+			idCol = i
+		}
+		if f := getField(col, fields); f != nil {
+			columns[i] = f
+			continue
+		}
+		// relationship columns look like Account.External_Id__c
+		if rel, _, ok := strings.Cut(col, "."); ok && relationshipField(rel, fields) != nil {
+			continue
+		}
+		report.add(0, col, "", "unknown column")
+	}
+
+	required := RequiredFields(fields)
+	for r, row := range data[1:] {
+		n := r + 1
+		if len(row) != len(header) {
+			report.add(n, "", "", "has %d values for %d columns", len(row), len(header))
+			continue
+		}
+		creating := idCol < 0 || row[idCol] == ""
+		for i, v := range row {
+			f := columns[i]
+			if f == nil {
+				continue
+			}
+			name := header[i]
+			if strings.EqualFold(name, "Id") {
+				if v != "" && !validId(v) {
+					report.add(n, name, v, "malformed Id")
+				}
+				continue
+			}
+			if v == "" {
+				continue
+			}
+			if creating && !f["createable"].(bool) {
+				report.add(n, name, v, "not createable")
+				continue
+			}
+			if !creating && !f["updateable"].(bool) {
+				report.add(n, name, v, "not updateable")
+				continue
+			}
+			if v == bulkNull {
+				if !f["nillable"].(bool) {
+					report.add(n, name, v, "can't be null")
+				}
+				continue
+			}
+			if problem := checkValue(f, v); problem != "" {
+				report.add(n, name, v, problem)
+			}
+		}
+		if creating {
+			for _, rf := range required {
+				i := columnIndex(header, rf)
+				if i < 0 || row[i] == "" || row[i] == bulkNull {
+					report.add(n, rf, "", "required")
+				}
+			}
+		}
+	}
+	return report
+}
+
+// returns the names of the fields that must have a value when a record is created,
+// createable, not nillable and without a default.
+func RequiredFields(fields []interface{}) []string {
+	var required []string
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		if IsRequired(field) {
+			required = append(required, field["name"].(string))
+		}
+	}
+	return required
+}
+
+// returns true if the field must be given a value on create.
+// Booleans are never nillable but always default to false.
+func IsRequired(field map[string]interface{}) bool {
+	return field["createable"].(bool) && !field["nillable"].(bool) && !field["defaultedOnCreate"].(bool) && field["type"].(string) != "boolean"
+}
+
+// returns a description of the problem with v, empty when it is fine
+func checkValue(f map[string]interface{}, v string) string {
+	switch f["type"].(string) {
+	case "string", "textarea", "email", "url", "phone", "encryptedstring", "combobox":
+		if l := int(f["length"].(float64)); l > 0 && len([]rune(v)) > l {
+			return fmt.Sprintf("longer than %d characters", l)
+		}
+	case "picklist":
+		if l := int(f["length"].(float64)); l > 0 && len([]rune(v)) > l {
+			return fmt.Sprintf("longer than %d characters", l)
+		}
+		if restricted(f) && !picklistHas(f, v) {
+			return "not a value of the restricted picklist"
+		}
+	case "multipicklist":
+		if restricted(f) {
+			for _, s := range strings.Split(v, ";") {
+				if !picklistHas(f, s) {
+					return "not a value of the restricted picklist"
+				}
+			}
+		}
+	case "currency", "double", "percent":
+		return checkNumber(v, int(f["precision"].(float64)), int(f["scale"].(float64)))
+	case "int":
+		return checkNumber(v, int(f["digits"].(float64)), 0)
+	case "boolean":
+		if _, err := strconv.ParseBool(v); err != nil {
+			return "not true or false"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "not a date (YYYY-MM-DD)"
+		}
+	case "datetime":
+		if !validDateTime(v) {
+			return "not a datetime (YYYY-MM-DDThh:mm:ss.sssZ)"
+		}
+	case "reference", "id":
+		if !validId(v) {
+			return "malformed Id"
+		}
+	}
+	return ""
+}
+
+func checkNumber(v string, precision int, scale int) string {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return "not a number"
+	}
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(math.Abs(n), 'f', -1, 64), ".")
+	whole = strings.TrimLeft(whole, "0")
+	if precision > 0 && len(whole) > precision-scale {
+		return fmt.Sprintf("more than %d digits before the decimal point", precision-scale)
+	}
+	if len(frac) > scale {
+		return fmt.Sprintf("more than %d decimal places", scale)
+	}
+	return ""
+}
+
+func validDateTime(v string) bool {
+	for _, l := range []string{"2006-01-02T15:04:05.000Z07:00", "2006-01-02T15:04:05.000-0700", time.RFC3339, "2006-01-02T15:04:05-0700", "2006-01-02T15:04:05Z"} {
+		if _, err := time.Parse(l, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checks the length, characters and, for 18 character Ids, the case-safe suffix
+func validId(v string) bool {
+	if !idExpr.MatchString(v) {
+		return false
+	}
+	if len(v) == 15 {
+		return true
+	}
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"
+	for block := 0; block < 3; block++ {
+		var flags int
+		for i := 0; i < 5; i++ {
+			c := v[block*5+i]
+			if c >= 'A' && c <= 'Z' {
+				flags |= 1 << i
+			}
+		}
+		if !strings.EqualFold(string(chars[flags]), string(v[15+block])) {
+			return false
+		}
+	}
+	return true
+}
+
+func restricted(f map[string]interface{}) bool {
+	r, _ := f["restrictedPicklist"].(bool)
+	return r
+}
+
+func picklistHas(f map[string]interface{}, v string) bool {
+	plv, _ := f["picklistValues"].([]interface{})
+	for _, p := range plv {
+		val := p.(map[string]interface{})
+		if active, ok := val["active"].(bool); ok && !active {
+			continue
+		}
+		if val["value"] == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validates the CSV file against the describe of obj according to cfg.Validate.
+// The report is logged and written next to the data as <obj>-validation.txt.
+// In strict mode a report with issues is returned as an error so nothing is uploaded.
+func validateUpload(cfg *config.Config, c *simpleforce.Client, csvfile string, obj string) error {
+	if strings.EqualFold(cfg.Validate, "off") {
+		return nil
+	}
+	meta := c.SObject(obj).Describe()
+	if meta == nil {
+		return fmt.Errorf("unable to describe %v to validate %v", obj, csvfile)
+	}
+	b, err := file.GetCSVBytes(csvfile)
+	if err != nil {
+		return err
+	}
+	data, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return err
+	}
+	report := ValidateCSV(meta, data)
+	log.Print(report)
+	fPath, err := file.BuildFilePath(fmt.Sprintf("%v-validation.txt", obj), cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fPath, []byte(report.String()), 0644); err != nil {
+		return err
+	}
+	if !report.Ok() && strings.EqualFold(cfg.Validate, "strict") {
+		return fmt.Errorf("%d validation issues in %v, see %v. Nothing has been uploaded", len(report.Issues), csvfile, fPath)
+	}
+	return nil
+}
+
+func relationshipField(rel string, fields []interface{}) map[string]interface{} {
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		if r, ok := field["relationshipName"].(string); ok && strings.EqualFold(r, rel) {
+			return field
+		}
+	}
+	return nil
+}
+
+func columnIndex(header []string, col string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, col) {
+			return i
+		}
+	}
+	return -1
+}
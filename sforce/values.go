@@ -0,0 +1,218 @@
+package sforce
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/troysellers/go-modifier/lorem"
+	"github.com/troysellers/go-modifier/random"
+)
+
+// returns the locale for each row of the data (not the header), that of the row's country where
+// the data has a country column, otherwise one of the run's locales
+func rowLocales(data [][]string) []*lorem.Locale {
+	if len(data) == 0 {
+		return nil
+	}
+	country := -1
+	for i, h := range data[0] {
+		if strings.HasSuffix(strings.ToLower(h), "country") {
+			country = i
+			break
+		}
+	}
+	locs := make([]*lorem.Locale, len(data)-1)
+	for r, row := range data[1:] {
+		if country >= 0 && country < len(row) {
+			locs[r] = lorem.ForCountry(row[country]).Record()
+		} else {
+			locs[r] = lorem.PickLocale().Record()
+		}
+	}
+	return locs
+}
+
+// returns a value from the locale for the fields that hold a name, an address, a company, an
+// email or a phone number, false for any other field
+func localeValue(sobj string, f map[string]interface{}, loc *lorem.Locale) (string, bool) {
+	if loc == nil {
+		return "", false
+	}
+	t, _ := f["type"].(string)
+	switch t {
+	case "email":
+		return truncate(loc.Email(), fieldLength(f)), true
+	case "phone":
+		return truncate(loc.Phone(), fieldLength(f)), true
+	}
+	if t != "string" && t != "textarea" {
+		return "", false
+	}
+	name := strings.ToLower(f["name"].(string))
+	var v string
+	switch {
+	case name == "firstname" || name == "middlename":
+		v = loc.FirstName()
+	case name == "lastname":
+		v = loc.LastName()
+	case name == "company" || name == "companyname":
+		v = loc.Company()
+	case name == "name":
+		switch strings.ToLower(sobj) {
+		case "account":
+			v = loc.Company()
+		case "contact", "lead", "user", "individual":
+			v = loc.FullName()
+		default:
+			return "", false
+		}
+	case strings.HasSuffix(name, "street"):
+		v = loc.Street()
+	case strings.HasSuffix(name, "city"):
+		v = loc.City()
+	case strings.HasSuffix(name, "state"):
+		v = loc.State()
+	case strings.HasSuffix(name, "postalcode"):
+		v = loc.PostalCode()
+	case strings.HasSuffix(name, "country"):
+		v = loc.Country
+	default:
+		return "", false
+	}
+	return truncate(v, fieldLength(f)), true
+}
+
+// returns a number that fits the precision and scale of the field. Latitudes and longitudes
+// (the parts of a geolocation field, or BillingLatitude and the like) stay on the globe.
+func numberValue(f map[string]interface{}) string {
+	p, _ := f["precision"].(float64)
+	s, _ := f["scale"].(float64)
+	scale := int(s)
+	whole := int(p) - scale
+	// lets not go crazy with big numbers
+	if whole > 9 {
+		whole = 9
+	}
+	max := math.Pow10(whole) - math.Pow10(-scale)
+	name := strings.ToLower(f["name"].(string))
+	switch {
+	case strings.HasSuffix(name, "latitude__s") || strings.HasSuffix(name, "latitude"):
+		max = math.Min(max, 90)
+		return strconv.FormatFloat(max-random.Float64()*2*max, 'f', scale, 64)
+	case strings.HasSuffix(name, "longitude__s") || strings.HasSuffix(name, "longitude"):
+		max = math.Min(max, 180)
+		return strconv.FormatFloat(max-random.Float64()*2*max, 'f', scale, 64)
+	case f["type"] == "percent":
+		max = math.Min(max, 100)
+	}
+	if max < 0 {
+		max = 0
+	}
+	// rounding up to max would add a digit, so the value is rounded down to the scale
+	v := math.Floor(random.Float64()*max*math.Pow10(scale)) / math.Pow10(scale)
+	return strconv.FormatFloat(v, 'f', scale, 64)
+}
+
+// returns a whole number with no more than the field's digits
+func intValue(f map[string]interface{}) string {
+	d, _ := f["digits"].(float64)
+	digits := int(d)
+	if digits <= 0 || digits > 18 {
+		digits = 18
+	}
+	return strconv.FormatInt(random.Int63n(int64(math.Pow10(digits))), 10)
+}
+
+// returns words that fit the length of the field
+func stringValue(f map[string]interface{}) string {
+	l := fieldLength(f)
+	n := 1
+	if l > 10 {
+		n = random.Intn(5) + 1
+	}
+	var ws []string
+	for i := 0; i < n; i++ {
+		ws = append(ws, lorem.Word(2, 10))
+	}
+	return truncate(strings.Join(ws, " "), l)
+}
+
+// returns sentences from the text model for a text area, a long text area gets paragraphs and a
+// rich one html paragraphs
+func textAreaValue(f map[string]interface{}) string {
+	l := fieldLength(f)
+	if l <= 255 {
+		return truncate(lorem.TextSentence(), l)
+	}
+	var ps []string
+	for i := 0; i < random.Intn(3)+1; i++ {
+		ps = append(ps, lorem.TextParagraph(2, 6))
+	}
+	if extra, _ := f["extraTypeInfo"].(string); extra == "richtextarea" {
+		return truncate("<p>"+strings.Join(ps, "</p><p>")+"</p>", l)
+	}
+	return truncate(strings.Join(ps, "\n\n"), l)
+}
+
+// returns a phone number of one of the run's locales that fits the length of the field
+func phoneValue(f map[string]interface{}) string {
+	return truncate(lorem.Phone(), fieldLength(f))
+}
+
+// returns a time of day as the bulk api writes them
+func timeValue() string {
+	return fmt.Sprintf("%02d:%02d:%02d.000Z", random.Intn(24), random.Intn(60), random.Intn(60))
+}
+
+// returns a small text file, base64 encoded
+func base64Value() string {
+	return base64.StdEncoding.EncodeToString([]byte(lorem.Paragraph(1, 3)))
+}
+
+// returns one of the active values of the picklist. A multi-select picklist gets up to 3 values joined with ;
+// and a combobox, or a picklist that isn't restricted and has no values, gets a word.
+func picklistValue(f map[string]interface{}) (string, error) {
+	var values []string
+	plv, _ := f["picklistValues"].([]interface{})
+	for _, p := range plv {
+		val := p.(map[string]interface{})
+		if active, ok := val["active"].(bool); ok && !active {
+			continue
+		}
+		values = append(values, val["value"].(string))
+	}
+	if len(values) == 0 {
+		if restricted(f) {
+			return "", fmt.Errorf("%v is a restricted picklist with no active values", f["name"])
+		}
+		return truncate(lorem.Word(3, 10), fieldLength(f)), nil
+	}
+	switch f["type"] {
+	case "combobox":
+		// a combobox takes any value, mostly use the ones it suggests
+		if random.Intn(4) == 0 {
+			return truncate(lorem.Word(3, 10), fieldLength(f)), nil
+		}
+	case "multipicklist":
+		n := random.Intn(int(math.Min(3, float64(len(values))))) + 1
+		random.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+		return strings.Join(values[:n], ";"), nil
+	}
+	return values[random.Intn(len(values))], nil
+}
+
+func fieldLength(f map[string]interface{}) int {
+	l, _ := f["length"].(float64)
+	return int(l)
+}
+
+// cuts v to l characters, if l is set
+func truncate(v string, l int) string {
+	if r := []rune(v); l > 0 && len(r) > l {
+		return strings.TrimSpace(string(r[:l]))
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/staging"
+)
+
+func init() {
+	register(command{name: "stage", summary: "list the datasets in the staging store or query it with SQL", run: stageCommand})
+}
+
+// lists the datasets of a run, or prints the results of a SQL query as CSV
+func stageCommand(args []string) error {
+	fs := newFlagSet("stage", "[-run <id>] [-sql \"select ...\"]")
+	var run = fs.String("run", "", "only list the datasets of this run")
+	var query = fs.String("sql", "", "a query to run against the staging store, the results are printed as CSV")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	cfg := config.NewConfig()
+	if cfg.Staging.Path == "" {
+		return configError("STAGING_DB is not set")
+	}
+	if _, err := os.Stat(cfg.Staging.Path); err != nil {
+		return configError("%v", err)
+	}
+	s, err := staging.Open(cfg.Staging.Path, "")
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if *query != "" {
+		rows, err := s.DB().Query(*query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		return writeRows(rows)
+	}
+	q := `select run_id, kind, object, dataset, table_name, rows from datasets`
+	var qargs []interface{}
+	if *run != "" {
+		q += ` where run_id = ?`
+		qargs = append(qargs, *run)
+	}
+	rows, err := s.DB().Query(q+` order by run_id, created`, qargs...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tKIND\tOBJECT\tDATASET\tTABLE\tROWS")
+	for rows.Next() {
+		var r, kind, obj, ds, table string
+		var n int
+		if err := rows.Scan(&r, &kind, &obj, &ds, &table, &n); err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%d\n", r, kind, obj, ds, table, n)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// prints the rows as CSV with a header
+func writeRows(rows *sql.Rows) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(os.Stdout)
+	w.Write(cols)
+	for rows.Next() {
+		values := make([]sql.NullString, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Error()
+}
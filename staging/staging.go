@@ -0,0 +1,387 @@
+package staging
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/troysellers/go-modifier/config"
+	"github.com/troysellers/go-modifier/file"
+	_ "modernc.org/sqlite"
+)
+
+/*
+The staging store keeps the data that passes through a run in a SQLite database,
+so it can be inspected with SQL afterwards rather than hunting through CSVs that overwrite each other.
+
+Every CSV is saved as a dataset of a kind (generated, query, modified, upload, success, failed)
+in a table named kind_object, e.g. generated_account. Each row is tagged with the run Id,
+the dataset name and its row number in the CSV. The datasets table lists them all.
+
+id_map holds the Ids Salesforce gave each uploaded row (and the Id it had before, if any)
+so generated rows can be joined with the records they created
+
+select g.*, m.new_id from generated_account g
+join id_map m on m.run_id = g.run_id and m.object = 'Account' and m.row_num = g.row_num
+where g.dataset = 'Account'
+*/
+type Store struct {
+	db    *sql.DB
+	RunId string
+	mu    sync.Mutex
+}
+
+// the kinds of dataset we keep
+const (
+	Generated string = "generated"
+	Query     string = "query"
+	Modified  string = "modified"
+	Upload    string = "upload"
+	Success   string = "success"
+	Failed    string = "failed"
+)
+
+// a row of the id_map, the Id Salesforce gave the row uploaded as row_num
+type IdMapping struct {
+	RowNum int
+	OldId  string
+	NewId  string
+}
+
+// the columns every data table starts with
+var metaColumns = []string{"run_id", "dataset", "row_num"}
+
+var tableExpr = regexp.MustCompile(`[^a-z0-9_]+`)
+
+var store *Store
+var storeMu sync.Mutex
+
+// returns the store configured with STAGING_DB, opening it the first time.
+// Returns nil when staging is off.
+func Get(cfg *config.Config) (*Store, error) {
+	if cfg.Staging.Path == "" {
+		return nil, nil
+	}
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	if store != nil {
+		return store, nil
+	}
+	runId := cfg.Staging.RunId
+	if runId == "" {
+		runId = time.Now().UTC().Format("20060102T150405Z")
+	}
+	s, err := Open(cfg.Staging.Path, runId)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Staging data in %v as run %v", cfg.Staging.Path, runId)
+	store = s
+	return store, nil
+}
+
+// opens (or creates) the database at path for the run
+func Open(path string, runId string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// one writer at a time, batches are saved from several goroutines
+	db.SetMaxOpenConns(1)
+	for _, ddl := range []string{
+		`create table if not exists runs (run_id text primary key, started text)`,
+		`create table if not exists datasets (run_id text, kind text, object text, dataset text, table_name text, columns text, rows integer, created text, primary key (run_id, kind, object, dataset))`,
+		`create table if not exists id_map (run_id text, object text, dataset text, row_num integer, old_id text, new_id text)`,
+		`create index if not exists id_map_new on id_map (new_id)`,
+		`create index if not exists id_map_old on id_map (object, old_id)`,
+	} {
+		if _, err := db.Exec(ddl); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("unable to set up staging database %v : %v", path, err)
+		}
+	}
+	// an empty run Id opens the store to read
+	if runId != "" {
+		if _, err := db.Exec(`insert or ignore into runs (run_id, started) values (?, ?)`, runId, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &Store{db: db, RunId: runId}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// gives direct access for queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// returns the table a kind of dataset for the object is kept in
+func TableName(kind string, object string) string {
+	return tableExpr.ReplaceAllString(strings.ToLower(kind+"_"+object), "_")
+}
+
+// saves the CSV at path as a dataset named after the file
+func (s *Store) SaveFile(kind string, object string, dataset string, path string) error {
+	data, err := file.ReadCsv(path)
+	if err != nil {
+		return err
+	}
+	return s.Save(kind, object, dataset, data)
+}
+
+// saves the data (header row first) as a dataset, replacing a dataset of the same name in this run
+func (s *Store) Save(kind string, object string, dataset string, data [][]string) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no header row for %v %v", kind, dataset)
+	}
+	header := data[0]
+	seen := make(map[string]bool)
+	for _, c := range append(append([]string{}, metaColumns...), header...) {
+		if seen[strings.ToLower(c)] {
+			return fmt.Errorf("%v can't be staged, the column %v is repeated or reserved", dataset, c)
+		}
+		seen[strings.ToLower(c)] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table := TableName(kind, object)
+	if err := s.ensureTable(table, header); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(fmt.Sprintf(`delete from %v where run_id = ? and dataset = ?`, quote(table)), s.RunId, dataset); err != nil {
+		return err
+	}
+	cols := append(append([]string{}, metaColumns...), header...)
+	quoted := make([]string, len(cols))
+	marks := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = quote(c)
+		marks[i] = "?"
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf(`insert into %v (%v) values (%v)`, quote(table), strings.Join(quoted, ","), strings.Join(marks, ",")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, row := range data[1:] {
+		values := []interface{}{s.RunId, dataset, i + 1}
+		for j := range header {
+			var v string
+			if j < len(row) {
+				v = row[j]
+			}
+			values = append(values, v)
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("staging row %d of %v : %v", i+1, dataset, err)
+		}
+	}
+	b, _ := json.Marshal(header)
+	if _, err := tx.Exec(`insert or replace into datasets (run_id, kind, object, dataset, table_name, columns, rows, created) values (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.RunId, kind, object, dataset, table, string(b), len(data)-1, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reads a dataset back as CSV rows (header first) in the order it was saved.
+// An empty runId is the latest run that has the dataset, an empty dataset the latest of the kind for the object.
+func (s *Store) Read(runId string, kind string, object string, dataset string) ([][]string, error) {
+	q := `select run_id, dataset, table_name, columns from datasets where kind = ? and lower(object) = lower(?)`
+	args := []interface{}{kind, object}
+	if runId != "" {
+		q += ` and run_id = ?`
+		args = append(args, runId)
+	}
+	if dataset != "" {
+		q += ` and dataset = ?`
+		args = append(args, dataset)
+	}
+	q += ` order by created desc, run_id desc limit 1`
+	var run, ds, table, columns string
+	if err := s.db.QueryRow(q, args...).Scan(&run, &ds, &table, &columns); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no %v dataset for %v in the staging store", kind, object)
+		}
+		return nil, err
+	}
+	var header []string
+	if err := json.Unmarshal([]byte(columns), &header); err != nil {
+		return nil, err
+	}
+	quoted := make([]string, len(header))
+	for i, h := range header {
+		quoted[i] = fmt.Sprintf("coalesce(%v, '')", quote(h))
+	}
+	rows, err := s.db.Query(fmt.Sprintf(`select %v from %v where run_id = ? and dataset = ? order by row_num`, strings.Join(quoted, ","), quote(table)), run, ds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	data := [][]string{header}
+	for rows.Next() {
+		row := make([]string, len(header))
+		ptrs := make([]interface{}, len(row))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		data = append(data, row)
+	}
+	return data, rows.Err()
+}
+
+// records the Ids given to the rows of an upload (dataset) for the object
+func (s *Store) SaveIds(object string, dataset string, ids []IdMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`insert into id_map (run_id, object, dataset, row_num, old_id, new_id) values (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range ids {
+		if _, err := stmt.Exec(s.RunId, object, dataset, m.RowNum, m.OldId, m.NewId); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// creates the table for the header, or adds the columns it doesn't have yet
+func (s *Store) ensureTable(table string, header []string) error {
+	existing := make(map[string]bool)
+	rows, err := s.db.Query(fmt.Sprintf(`pragma table_info(%v)`, quote(table)))
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[strings.ToLower(name)] = true
+	}
+	rows.Close()
+	if len(existing) == 0 {
+		if _, err := s.db.Exec(fmt.Sprintf(`create table %v (run_id text, dataset text, row_num integer)`, quote(table))); err != nil {
+			return err
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`create index %v on %v (run_id, dataset)`, quote(table+"_run"), quote(table))); err != nil {
+			return err
+		}
+		for _, c := range metaColumns {
+			existing[c] = true
+		}
+	}
+	for _, h := range header {
+		if existing[strings.ToLower(h)] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(`alter table %v add column %v text`, quote(table), quote(h))); err != nil {
+			return err
+		}
+		existing[strings.ToLower(h)] = true
+	}
+	return nil
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// saves the data to the configured store, if there is one.
+// Staging is a record of the run so a failure is logged rather than stopping it.
+func Record(cfg *config.Config, kind string, object string, dataset string, data [][]string) {
+	s, err := Get(cfg)
+	if err == nil && s != nil {
+		err = s.Save(kind, object, dataset, data)
+	}
+	if err != nil {
+		log.Printf("Unable to stage %v %v : %v", kind, dataset, err)
+	}
+}
+
+// saves the CSV at path to the configured store, if there is one
+func RecordFile(cfg *config.Config, kind string, object string, dataset string, path string) {
+	if cfg.Staging.Path == "" {
+		return
+	}
+	data, err := file.ReadCsv(path)
+	if err != nil {
+		log.Printf("Unable to stage %v %v : %v", kind, dataset, err)
+		return
+	}
+	Record(cfg, kind, object, dataset, data)
+}
+
+// matches the successful results of an ingest job (sf__Id, sf__Created then the columns as sent)
+// to the rows of the uploaded data, returning the Id each uploaded row was given
+func MatchIds(uploaded [][]string, successful [][]string) []IdMapping {
+	if len(uploaded) == 0 || len(successful) == 0 {
+		return nil
+	}
+	key := func(header []string, row []string) string {
+		var sb strings.Builder
+		for _, col := range uploaded[0] {
+			for i, h := range header {
+				if strings.EqualFold(h, col) && i < len(row) {
+					sb.WriteString(row[i])
+				}
+			}
+			sb.WriteByte(0)
+		}
+		return sb.String()
+	}
+	idCol := -1
+	for i, h := range uploaded[0] {
+		if strings.EqualFold(h, "Id") {
+			idCol = i
+		}
+	}
+	// identical rows are matched in order
+	rowsFor := make(map[string][]int)
+	for i, row := range uploaded[1:] {
+		k := key(uploaded[0], row)
+		rowsFor[k] = append(rowsFor[k], i+1)
+	}
+	var ids []IdMapping
+	for _, row := range successful[1:] {
+		k := key(successful[0], row)
+		rows := rowsFor[k]
+		if len(rows) == 0 {
+			continue
+		}
+		rowsFor[k] = rows[1:]
+		m := IdMapping{RowNum: rows[0], NewId: row[0]}
+		if idCol >= 0 {
+			m.OldId = uploaded[rows[0]][idCol]
+		}
+		ids = append(ids, m)
+	}
+	return ids
+}
@@ -0,0 +1,97 @@
+package staging
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func open(t *testing.T, runId string) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "staging.db"), runId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSaveAndRead(t *testing.T) {
+	s := open(t, "run1")
+	first := [][]string{{"Name", "Phone"}, {"Acme", "555"}, {"Globex", ""}}
+	if err := s.Save(Generated, "Account", "Account", first); err != nil {
+		t.Fatal(err)
+	}
+	// a later dataset with a new column adds it to the table
+	second := [][]string{{"Name", "Account.Name", "Website"}, {"Initech", "Acme", "initech.com"}}
+	if err := s.Save(Generated, "Account", "Account-person", second); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Read("run1", Generated, "account", "Account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(first, got); diff != "" {
+		t.Error(diff)
+	}
+	got, err = s.Read("", Generated, "Account", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(second, got); diff != "" {
+		t.Errorf("expected the latest dataset\n%v", diff)
+	}
+	// saving the same dataset again replaces it
+	if err := s.Save(Generated, "Account", "Account", first[:2]); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := s.DB().QueryRow(`select count(*) from generated_account where dataset = 'Account'`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 row after replacing the dataset, got %d", n)
+	}
+	if _, err := s.Read("", Query, "Account", ""); err == nil {
+		t.Error("expected an error reading a dataset that isn't there")
+	}
+	if err := s.Save(Query, "Account", "x", [][]string{{"Id", "id"}}); err == nil {
+		t.Error("expected an error for a repeated column")
+	}
+}
+
+func TestMatchIds(t *testing.T) {
+	uploaded := [][]string{
+		{"Id", "Name", "Phone"},
+		{"", "Acme", "555"},
+		{"001000000000009AAA", "Globex", ""},
+		{"", "Acme", "555"},
+		{"", "Failed Co", ""},
+	}
+	successful := [][]string{
+		{"sf__Id", "sf__Created", "Id", "Name", "Phone"},
+		{"001000000000009AAA", "false", "001000000000009AAA", "Globex", ""},
+		{"001000000000001AAA", "true", "", "Acme", "555"},
+		{"001000000000002AAA", "true", "", "Acme", "555"},
+	}
+	want := []IdMapping{
+		{RowNum: 2, OldId: "001000000000009AAA", NewId: "001000000000009AAA"},
+		{RowNum: 1, NewId: "001000000000001AAA"},
+		{RowNum: 3, NewId: "001000000000002AAA"},
+	}
+	if diff := cmp.Diff(want, MatchIds(uploaded, successful)); diff != "" {
+		t.Error(diff)
+	}
+
+	s := open(t, "run1")
+	if err := s.SaveIds("Account", "750000000000001AAA", want); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := s.DB().QueryRow(`select count(*) from id_map where run_id = 'run1' and new_id != ''`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 ids got %d", n)
+	}
+}
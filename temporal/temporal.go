@@ -0,0 +1,350 @@
+package temporal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/troysellers/go-modifier/file"
+	"github.com/troysellers/go-modifier/random"
+)
+
+/*
+	A Constraint keeps generated dates consistent with each other.
+
+	Constraints are written as Field op Source [+|- min[..max]unit] [when|unless Checkbox]
+
+	ClosedDate >= CreatedDate                       repaired to CreatedDate when it is earlier
+	EndDateTime = StartDateTime + 30..240m          always set 30 to 240 minutes after the start
+	CompletedDateTime <= now                        nothing completes in the future
+	CloseDate >= today + 1..180d
+	EndDateTime = StartDateTime when IsAllDayEvent  all day events end on the day they start
+
+	op is one of = >= > <= <, Source is a field or now/today and unit is m, h or d. With when the
+	constraint only applies to rows where the checkbox is true, with unless to those where it isn't.
+	A row is repaired by setting Field to Source plus a random offset in the range.
+	Rows where Source can't be read as a date are rejected.
+*/
+type Constraint struct {
+	Field  string
+	Op     string
+	Source string
+	Min    time.Duration // offset from Source, already signed
+	Max    time.Duration
+	When   string // a checkbox field, the constraint only applies to rows where it is true
+	Unless string // a checkbox field, the constraint only applies to rows where it isn't true
+}
+
+// constraints applied to every run for these objects, configured constraints on the same field replace them
+var Defaults = map[string][]string{
+	"Event": {
+		"StartDateTime = ActivityDate + 480..1020m unless IsAllDayEvent",
+		"EndDateTime = StartDateTime + 30..240m unless IsAllDayEvent",
+		"StartDateTime = ActivityDate when IsAllDayEvent",
+		"EndDateTime = StartDateTime when IsAllDayEvent",
+	},
+	"Task": {
+		"CompletedDateTime <= now",
+	},
+	"Case": {
+		"ClosedDate >= CreatedDate",
+	},
+}
+
+const DateFormat string = "2006-01-02"
+const DateTimeFormat string = "2006-01-02T15:04:05.000Z"
+
+// layouts we accept when reading generated or queried values
+var layouts = []string{
+	"2006-01-02T15:04:05.000-0700",
+	"2006-01-02T15:04:05.000Z",
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+	DateFormat,
+	"1/2/2006",
+	"01/02/2006",
+}
+
+var constraintExpr = regexp.MustCompile(`^\s*(\w+)\s*(>=|<=|=|>|<)\s*(\w+)\s*(?:([+-])\s*(\d+)(?:\.\.(\d+))?\s*([mhd]))?\s*(?:(when|unless)\s+(\w+))?\s*$`)
+
+// parses a single constraint such as "EndDateTime = StartDateTime + 30..240m"
+func Parse(s string) (Constraint, error) {
+	m := constraintExpr.FindStringSubmatch(s)
+	if m == nil {
+		return Constraint{}, fmt.Errorf("unable to parse date constraint [%v]", s)
+	}
+	c := Constraint{Field: m[1], Op: m[2], Source: m[3]}
+	switch m[8] {
+	case "when":
+		c.When = m[9]
+	case "unless":
+		c.Unless = m[9]
+	}
+	if m[4] == "" {
+		return c, nil
+	}
+	unit := map[string]time.Duration{"m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}[m[7]]
+	lo, _ := strconv.Atoi(m[5])
+	hi := lo
+	if m[6] != "" {
+		hi, _ = strconv.Atoi(m[6])
+	}
+	if hi < lo {
+		return Constraint{}, fmt.Errorf("range in date constraint must be low..high [%v]", s)
+	}
+	sign := time.Duration(1)
+	if m[4] == "-" {
+		sign = -1
+	}
+	c.Min = sign * time.Duration(lo) * unit
+	c.Max = sign * time.Duration(hi) * unit
+	if c.Min > c.Max {
+		c.Min, c.Max = c.Max, c.Min
+	}
+	return c, nil
+}
+
+// returns the default constraints for obj combined with the configured ones.
+// Configured entries are written Object:constraint, a configured constraint replaces one on the same field
+// for the same rows. One for every row also replaces the defaults on the field for some of them.
+func ForObject(obj string, configured []string) ([]Constraint, error) {
+	var cs []Constraint
+	var isDefault []bool
+	add := func(s string, byDefault bool) error {
+		c, err := Parse(s)
+		if err != nil {
+			return err
+		}
+		for i, d := range cs {
+			if strings.EqualFold(d.Field, c.Field) && strings.EqualFold(d.When, c.When) && strings.EqualFold(d.Unless, c.Unless) {
+				cs[i], isDefault[i] = c, byDefault
+				return nil
+			}
+		}
+		if c.When == "" && c.Unless == "" {
+			replaced := false
+			for i := 0; i < len(cs); i++ {
+				if !isDefault[i] || !strings.EqualFold(cs[i].Field, c.Field) {
+					continue
+				}
+				if !replaced {
+					cs[i], isDefault[i] = c, byDefault
+					replaced = true
+					continue
+				}
+				cs, isDefault = append(cs[:i], cs[i+1:]...), append(isDefault[:i], isDefault[i+1:]...)
+				i--
+			}
+			if replaced {
+				return nil
+			}
+		}
+		cs, isDefault = append(cs, c), append(isDefault, byDefault)
+		return nil
+	}
+	for o, defaults := range Defaults {
+		if strings.EqualFold(o, obj) {
+			for _, d := range defaults {
+				if err := add(d, true); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, entry := range configured {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		o, s, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("date constraints are written Object:constraint [%v]", entry)
+		}
+		if strings.EqualFold(strings.TrimSpace(o), obj) {
+			if err := add(s, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cs, nil
+}
+
+// returns the salesforce type (date or datetime) of the date fields in a describe
+func FieldTypes(fields []interface{}) map[string]string {
+	types := make(map[string]string)
+	for _, f := range fields {
+		field := f.(map[string]interface{})
+		if t := field["type"].(string); t == "date" || t == "datetime" {
+			types[field["name"].(string)] = t
+		}
+	}
+	return types
+}
+
+// applies the constraints to the data (header row first) in order.
+// Returns the rows that could be satisfied, repairing them in place, and how many were rejected.
+// Constraints whose fields aren't in the header don't apply and are skipped.
+func Apply(data [][]string, constraints []Constraint, types map[string]string) ([][]string, int) {
+	if len(data) == 0 {
+		return data, 0
+	}
+	now := random.Now().UTC()
+	rejected := make([]bool, len(data))
+	for _, c := range constraints {
+		fi := indexOf(data[0], c.Field)
+		si := indexOf(data[0], c.Source)
+		isNow := strings.EqualFold(c.Source, "now") || strings.EqualFold(c.Source, "today")
+		if fi < 0 || (si < 0 && !isNow) {
+			log.Printf("Skipping date constraint on %v, the fields aren't in the data", c.Field)
+			continue
+		}
+		wi, ui := -1, -1
+		if c.When != "" {
+			if wi = indexOf(data[0], c.When); wi < 0 {
+				continue // no rows it applies to
+			}
+		}
+		if c.Unless != "" {
+			ui = indexOf(data[0], c.Unless)
+		}
+		fieldType := typeOf(types, c.Field)
+		for r, row := range data[1:] {
+			if rejected[r+1] || (wi >= 0 && !checked(row[wi])) || (ui >= 0 && checked(row[ui])) {
+				continue
+			}
+			var source time.Time
+			switch {
+			case strings.EqualFold(c.Source, "now"):
+				source = now
+			case strings.EqualFold(c.Source, "today"):
+				source = now.Truncate(24 * time.Hour)
+			case row[si] == "":
+				continue // nothing to be consistent with
+			default:
+				var err error
+				if source, err = parse(row[si]); err != nil {
+					log.Printf("Rejecting row %d, %v [%v] is not a date", r+1, c.Source, row[si])
+					rejected[r+1] = true
+					continue
+				}
+			}
+			if c.Op != "=" {
+				if v, err := parse(row[fi]); row[fi] == "" || (err == nil && c.satisfied(v, source)) {
+					continue
+				}
+			}
+			row[fi] = format(c.repair(source), fieldType)
+		}
+	}
+	kept := [][]string{data[0]}
+	var n int
+	for i, row := range data[1:] {
+		if rejected[i+1] {
+			n++
+			continue
+		}
+		kept = append(kept, row)
+	}
+	return kept, n
+}
+
+// reads the CSV at filePath, applies the constraints and writes it back.
+// returns how many rows were rejected.
+func ApplyToFile(filePath string, constraints []Constraint, types map[string]string) (int, error) {
+	if len(constraints) == 0 {
+		return 0, nil
+	}
+	b, err := file.GetCSVBytes(filePath)
+	if err != nil {
+		return 0, err
+	}
+	data, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return 0, err
+	}
+	kept, rejected := Apply(data, constraints, types)
+	if _, err := file.WriteCsv(filePath, kept); err != nil {
+		return 0, err
+	}
+	return rejected, nil
+}
+
+func (c Constraint) satisfied(v time.Time, source time.Time) bool {
+	switch c.Op {
+	case ">=":
+		return !v.Before(source.Add(c.Min))
+	case ">":
+		return v.After(source.Add(c.Min))
+	case "<=":
+		return !v.After(source.Add(c.Max))
+	case "<":
+		return v.Before(source.Add(c.Max))
+	}
+	return v.Equal(source.Add(c.Min))
+}
+
+// returns a value that satisfies the constraint
+func (c Constraint) repair(source time.Time) time.Time {
+	offset := c.Min
+	if c.Max > c.Min {
+		offset += time.Duration(random.Int63n(int64(c.Max - c.Min)))
+	}
+	switch c.Op {
+	case ">":
+		if offset == c.Min {
+			offset += time.Minute
+		}
+	case "<":
+		if offset == c.Max {
+			offset -= time.Minute
+		}
+	}
+	return source.Add(offset)
+}
+
+func parse(s string) (time.Time, error) {
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %v as a date", s)
+}
+
+// formats the time for the bulk api
+func format(t time.Time, fieldType string) string {
+	if fieldType == "date" {
+		return t.Format(DateFormat)
+	}
+	return t.UTC().Format(DateTimeFormat)
+}
+
+// the type of the field, the constraint may not name it with the case describe does
+func typeOf(types map[string]string, field string) string {
+	for f, t := range types {
+		if strings.EqualFold(f, field) {
+			return t
+		}
+	}
+	return ""
+}
+
+// true if a checkbox value is checked
+func checked(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.EqualFold(v, "true") || v == "1"
+}
+
+func indexOf(header []string, col string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, col) {
+			return i
+		}
+	}
+	return -1
+}
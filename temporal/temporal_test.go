@@ -0,0 +1,96 @@
+package temporal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse("EndDateTime = StartDateTime + 30..240m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Field != "EndDateTime" || c.Op != "=" || c.Source != "StartDateTime" || c.Min != 30*time.Minute || c.Max != 240*time.Minute {
+		t.Errorf("unexpected constraint %+v", c)
+	}
+	c, err = Parse("ActivityDate >= today - 1..3d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Min != -72*time.Hour || c.Max != -24*time.Hour {
+		t.Errorf("unexpected offsets %+v", c)
+	}
+	for _, bad := range []string{"ClosedDate", "ClosedDate => CreatedDate", "A = B + 5..1m", "A = B + 5w"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestForObject(t *testing.T) {
+	cs, err := ForObject("event", []string{"", "Event:EndDateTime = StartDateTime + 60m", "Case:ClosedDate > CreatedDate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 3 || cs[1].Field != "EndDateTime" || cs[1].Min != time.Hour || cs[1].Unless != "" {
+		t.Errorf("configured constraint should replace the defaults %+v", cs)
+	}
+}
+
+func TestApply(t *testing.T) {
+	data := [][]string{
+		{"Id", "CreatedDate", "ClosedDate", "StartDateTime", "EndDateTime"},
+		{"1", "2024-03-10T10:00:00.000+0000", "2024-03-01", "2024-03-10T10:00:00.000Z", ""},
+		{"2", "2024-03-10T10:00:00.000+0000", "2024-04-01", "", ""},
+		{"3", "not a date", "2024-04-01", "", ""},
+	}
+	cs := []Constraint{}
+	for _, s := range []string{"ClosedDate >= CreatedDate", "EndDateTime = StartDateTime + 30..240m"} {
+		c, _ := Parse(s)
+		cs = append(cs, c)
+	}
+	types := map[string]string{"ClosedDate": "date", "EndDateTime": "datetime"}
+	kept, rejected := Apply(data, cs, types)
+	if rejected != 1 || len(kept) != 3 {
+		t.Fatalf("expected one rejected row, got %d and %v", rejected, kept)
+	}
+	if kept[1][2] != "2024-03-10" {
+		t.Errorf("ClosedDate should be repaired to the created date, got %v", kept[1][2])
+	}
+	if kept[2][2] != "2024-04-01" {
+		t.Errorf("ClosedDate that satisfied the constraint changed to %v", kept[2][2])
+	}
+	end, err := time.Parse(DateTimeFormat, kept[1][4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)
+	if end.Before(start.Add(30*time.Minute)) || end.After(start.Add(240*time.Minute)) {
+		t.Errorf("EndDateTime %v is not 30 to 240 minutes after the start", end)
+	}
+	if kept[2][4] != "" {
+		t.Errorf("EndDateTime should stay blank without a start, got %v", kept[2][4])
+	}
+}
+
+func TestApplyAllDay(t *testing.T) {
+	data := [][]string{
+		{"ActivityDate", "IsAllDayEvent", "StartDateTime", "EndDateTime"},
+		{"2024-03-10", "false", "2023-06-01T09:00:00.000Z", "2023-01-01T09:00:00.000Z"},
+		{"2024-03-10", "true", "2023-06-01T09:00:00.000Z", "2023-01-01T09:00:00.000Z"},
+	}
+	cs, err := ForObject("Event", []string{"event:startdatetime = activitydate + 540m unless isalldayevent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, _ := Apply(data, cs, map[string]string{"ActivityDate": "date", "StartDateTime": "datetime", "EndDateTime": "datetime"})
+	if kept[1][2] != "2024-03-10T09:00:00.000Z" {
+		t.Errorf("expected the start at 9am written as a datetime, got %v", kept[1][2])
+	}
+	if end, _ := time.Parse(DateTimeFormat, kept[1][3]); end.Sub(time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)) < 30*time.Minute {
+		t.Errorf("expected the end after the start, got %v", kept[1][3])
+	}
+	if kept[2][2] != "2024-03-10T00:00:00.000Z" || kept[2][3] != "2024-03-10T00:00:00.000Z" {
+		t.Errorf("expected an all day event to start and end on its day, got %v", kept[2])
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/troysellers/go-modifier/lorem"
+)
+
+func init() {
+	register(command{name: "train-text", summary: "train the text model descriptions and comments are made from, from a CSV of real text", run: trainTextCommand})
+}
+
+// trains a text model from columns of a CSV and saves it for TEXT_MODEL
+func trainTextCommand(args []string) error {
+	fs := newFlagSet("train-text", "-csv <file.csv> -column Description[,Subject] -out <model.json> [-order 2] [-sample 3]")
+	var path = fs.String("csv", "", "a CSV with a header row, such as an export of Case descriptions")
+	var columns = fs.String("column", "", "comma separated columns of the CSV to train from")
+	var out = fs.String("out", "", "the file to save the model to, set TEXT_MODEL to it")
+	var order = fs.Int("order", lorem.DefaultOrder, "how many words the next word depends on, more reads closer to the corpus but repeats it")
+	var sample = fs.Int("sample", 3, "print this many sentences from the trained model")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *path == "" || *columns == "" || *out == "" {
+		return configError("-csv, -column and -out are required")
+	}
+	if *order < 1 {
+		return configError("-order must be at least 1")
+	}
+	m, err := lorem.TrainCSV(*path, strings.Split(*columns, ","), *order)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(*out); err != nil {
+		return err
+	}
+	fmt.Printf("Saved the text model to %v, %d prefixes\n", *out, len(m.Chain))
+	for i := 0; i < *sample; i++ {
+		fmt.Println(m.Sentence())
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package unique
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+/*
+Values for unique and external Id fields are built from a pattern and a sequence
+that is persisted between runs, so seeding the same org twice never collides.
+
+A pattern is literal text around a single {000000} placeholder, the number of zeros is
+the width the sequence is padded to. ACC-{000000} gives ACC-000001, ACC-000002 ...
+*/
+type Pattern struct {
+	Prefix string
+	Suffix string
+	Width  int
+}
+
+var placeholder = regexp.MustCompile(`\{(0+)\}`)
+
+// parses a pattern such as ACC-{000000}
+func ParsePattern(p string) (Pattern, error) {
+	locs := placeholder.FindAllStringSubmatchIndex(p, -1)
+	if len(locs) != 1 {
+		return Pattern{}, fmt.Errorf("unique pattern needs exactly one {000} placeholder [%v]", p)
+	}
+	l := locs[0]
+	return Pattern{Prefix: p[:l[0]], Suffix: p[l[1]:], Width: l[3] - l[2]}, nil
+}
+
+// returns a pattern that fits in length characters,
+// a short prefix from the object name followed by as many digits as we sensibly need.
+func DefaultPattern(obj string, length int) Pattern {
+	var prefix string
+	for _, r := range strings.ToUpper(obj) {
+		if len(prefix) == 3 {
+			break
+		}
+		if unicode.IsLetter(r) {
+			prefix += string(r)
+		}
+	}
+	prefix += "-"
+	if length < len(prefix)+6 {
+		return Pattern{Width: length}
+	}
+	width := length - len(prefix)
+	if width > 12 {
+		width = 12
+	}
+	return Pattern{Prefix: prefix, Width: width}
+}
+
+// formats the nth value
+func (p Pattern) Format(n int64) string {
+	return fmt.Sprintf("%v%0*d%v", p.Prefix, p.Width, n, p.Suffix)
+}
+
+// returns the sequence number of a value made by this pattern
+func (p Pattern) Parse(v string) (int64, bool) {
+	if !strings.HasPrefix(v, p.Prefix) || !strings.HasSuffix(v, p.Suffix) || len(v) < len(p.Prefix)+len(p.Suffix) {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[len(p.Prefix):len(v)-len(p.Suffix)], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// returns the longest value the pattern makes before the sequence outgrows its width
+func (p Pattern) Len() int {
+	return len(p.Prefix) + p.Width + len(p.Suffix)
+}
+
+// the last value used for each Object.Field, saved as json
+type Sequences struct {
+	path   string
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+// loads the sequences saved at path, a missing file starts every sequence at zero
+func OpenSequences(path string) (*Sequences, error) {
+	s := &Sequences{path: path, values: make(map[string]int64)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.values); err != nil {
+		return nil, fmt.Errorf("unable to read sequences from %v : %v", path, err)
+	}
+	return s, nil
+}
+
+// returns the next value in the sequence for key. floor is the highest value already
+// known to be in use (e.g. found in the org), the sequence always moves past it.
+func (s *Sequences) Next(key string, floor int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.values[key]
+	if floor > n {
+		n = floor
+	}
+	n++
+	s.values[key] = n
+	return n
+}
+
+// writes the sequences back to disk
+func (s *Sequences) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, b, 0644)
+}
@@ -0,0 +1,69 @@
+package unique
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	p, err := ParsePattern("ACC-{000000}-X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Prefix != "ACC-" || p.Suffix != "-X" || p.Width != 6 {
+		t.Errorf("parsed %+v", p)
+	}
+	if v := p.Format(42); v != "ACC-000042-X" {
+		t.Errorf("formatted %v", v)
+	}
+	if n, ok := p.Parse("ACC-000042-X"); !ok || n != 42 {
+		t.Errorf("parsed back %d %v", n, ok)
+	}
+	if _, ok := p.Parse("OPP-000042-X"); ok {
+		t.Error("parsed a value from another pattern")
+	}
+	if p.Len() != 12 {
+		t.Errorf("expected length 12 got %d", p.Len())
+	}
+	for _, bad := range []string{"ACC", "{00}-{00}"} {
+		if _, err := ParsePattern(bad); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func TestDefaultPattern(t *testing.T) {
+	if p := DefaultPattern("My_Object__c", 80); p.Prefix != "MYO-" || p.Width != 12 {
+		t.Errorf("got %+v", p)
+	}
+	if p := DefaultPattern("Account", 10); p.Len() > 10 || p.Prefix != "ACC-" {
+		t.Errorf("got %+v", p)
+	}
+	if p := DefaultPattern("Account", 6); p.Len() != 6 || p.Prefix != "" {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestSequences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.json")
+	s, err := OpenSequences(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Next("Account.Ext__c", 0); n != 1 {
+		t.Errorf("expected 1 got %d", n)
+	}
+	if n := s.Next("Account.Ext__c", 100); n != 101 {
+		t.Errorf("expected the floor to be skipped, got %d", n)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenSequences(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Next("Account.Ext__c", 0); n != 102 {
+		t.Errorf("expected the saved sequence to continue at 102, got %d", n)
+	}
+}